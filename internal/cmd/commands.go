@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+	"github.com/hiragram/agent-workspace/internal/update"
+	"github.com/hiragram/agent-workspace/internal/version"
+)
+
+// command is a subcommand of aw, such as `aw doctor`. Anything that is not
+// a command is taken as a profile name.
+type command struct {
+	name    string
+	usage   string // arguments, e.g. "[--json]"
+	summary string
+
+	// flags returns the command's flags, for help and completion; nil if it
+	// takes none. The run function parses its arguments with the same set.
+	flags func() *flag.FlagSet
+	// complete returns the candidates for positional argument number
+	// len(args), where args are the ones before it and cur is the partial
+	// argument; nil if it takes none.
+	complete func(args []string, cur string) []string
+
+	run func(args []string) int
+}
+
+// commands is the list of subcommands, in the order `aw help` shows them.
+// It is set in init because `aw help` and completion refer to it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{
+			name:    "init",
+			usage:   "[--non-interactive] [--force]",
+			summary: "create .agent-workspace.yml for the current repository",
+			flags:   func() *flag.FlagSet { return newInitFlags(new(initOptions)) },
+			run:     runInit,
+		},
+		{
+			name:     "open",
+			usage:    "<worktree-name>",
+			summary:  "resume a worktree created by aw, with the profile it was created with",
+			complete: completeWorktrees,
+			run:      runOpen,
+		},
+		{
+			name:    "profiles",
+			usage:   "[--json]",
+			summary: "list the profiles of the current repository",
+			flags:   func() *flag.FlagSet { return newProfilesFlags(new(bool)) },
+			run:     runProfiles,
+		},
+		{
+			name:     "config",
+			usage:    "show [profile] | validate [file] | schema",
+			summary:  "print the merged config, check a config file, or print its JSON Schema",
+			complete: completeConfig,
+			run:      runConfig,
+		},
+		{
+			name:    "doctor",
+			usage:   "[--json]",
+			summary: "check that git, docker, zellij or tmux, and the config are set up",
+			flags:   func() *flag.FlagSet { return newDoctorFlags(new(bool)) },
+			run:     runDoctor,
+		},
+		{
+			name:    "update",
+			summary: "update aw to the latest release",
+			run:     runUpdate,
+		},
+		{
+			name:    "default-dockerfile",
+			summary: "print the Dockerfile of the default image",
+			run:     func([]string) int { return runDefaultDockerfile() },
+		},
+		{
+			name:     "completion",
+			usage:    "bash|zsh|fish",
+			summary:  "print a shell completion script",
+			complete: func(args []string, _ string) []string { return positional(args, 0, completionShells) },
+			run:      runCompletion,
+		},
+		{
+			name:     "help",
+			usage:    "[command]",
+			summary:  "show help for aw or a command",
+			complete: func(args []string, _ string) []string { return positional(args, 0, commandNames()) },
+			run:      runHelp,
+		},
+	}
+	profile.ReservedNames = append(commandNames(), completeCommand)
+}
+
+// findCommand returns the command called name, or nil if there is none.
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// commandNames returns the names of the commands.
+func commandNames() []string {
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// positional returns candidates if the argument being completed is
+// positional argument number n, and nil otherwise.
+func positional(args []string, n int, candidates []string) []string {
+	if len(args) != n {
+		return nil
+	}
+	return candidates
+}
+
+// runHelp implements `aw help [command]`.
+func runHelp(args []string) int {
+	switch len(args) {
+	case 0:
+		printHelp(os.Stdout)
+		return 0
+	case 1:
+		c := findCommand(args[0])
+		if c == nil {
+			fmt.Fprintf(os.Stderr, "unknown command: %q (run aw help for the list)\n", args[0])
+			return 2
+		}
+		printCommandHelp(os.Stdout, c)
+		return 0
+	}
+	fmt.Fprintln(os.Stderr, "Usage: aw help [command]")
+	return 2
+}
+
+// printHelp writes the overview of aw: how to run a profile, its flags,
+// and the commands.
+func printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: aw [profile] [flags]   run a profile (the default profile if none is given)")
+	fmt.Fprintln(w, "       aw <command> [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fs := newRunFlags(new(runOptions))
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run `aw help <command>` for the arguments of a command, and `aw --version` for the version.")
+}
+
+// printCommandHelp writes the usage and flags of c.
+func printCommandHelp(w io.Writer, c *command) {
+	fmt.Fprintln(w, strings.TrimSpace(fmt.Sprintf("Usage: aw %s %s", c.name, c.usage)))
+	fmt.Fprintln(w)
+	// The summary is a lowercase phrase for the command list
+	fmt.Fprintln(w, strings.ToUpper(c.summary[:1])+c.summary[1:]+".")
+	if c.flags != nil {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Flags:")
+		fs := c.flags()
+		fs.SetOutput(w)
+		fs.PrintDefaults()
+	}
+}
+
+// runUpdate implements `aw update`.
+func runUpdate(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: aw update")
+		return 2
+	}
+	if err := update.Run(version.Version); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+	"github.com/hiragram/agent-workspace/internal/worktree"
+)
+
+// completeCommand is the hidden command the completion scripts run:
+// `aw __complete <word>... <current word>` prints the candidates for the
+// current word, one per line.
+const completeCommand = "__complete"
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// completionScripts call back into aw, so that profile names come from the
+// config of the repository the shell is in.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for aw. Load it with:
+#   source <(aw completion bash)
+_aw() {
+	local IFS=$'\n'
+	COMPREPLY=($(aw __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+	if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == */ ]]; then
+		compopt -o nospace
+	fi
+}
+complete -F _aw aw
+`,
+	"zsh": `#compdef aw
+# zsh completion for aw. Load it (after compinit) with:
+#   source <(aw completion zsh)
+# or save it as _aw in a directory of your $fpath.
+_aw() {
+	local -a candidates
+	candidates=("${(@f)$(aw __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	candidates=(${candidates:#})
+	compadd -S '' -- ${(M)candidates:#*/}
+	compadd -- ${candidates:#*/}
+}
+if [[ $funcstack[1] == _aw ]]; then
+	_aw "$@"
+else
+	compdef _aw aw
+fi
+`,
+	"fish": `# fish completion for aw. Load it with:
+#   aw completion fish | source
+# or save it as ~/.config/fish/completions/aw.fish.
+complete -c aw -f -a '(aw __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`,
+}
+
+// runCompletion implements `aw completion bash|zsh|fish`.
+func runCompletion(args []string) int {
+	if len(args) != 1 || completionScripts[args[0]] == "" {
+		fmt.Fprintln(os.Stderr, "Usage: aw completion bash|zsh|fish")
+		return 2
+	}
+	fmt.Print(completionScripts[args[0]])
+	return 0
+}
+
+// runComplete implements `aw __complete`.
+func runComplete(args []string) int {
+	for _, c := range complete(args) {
+		fmt.Println(c)
+	}
+	return 0
+}
+
+// complete returns the candidates for the last of words, the arguments of
+// aw up to the word being completed.
+func complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	args, cur := words[:len(words)-1], words[len(words)-1]
+
+	var candidates []string
+	switch {
+	case len(args) == 0 && !strings.HasPrefix(cur, "-"):
+		candidates = append(commandNames(), profileNames()...)
+	case len(args) > 0 && findCommand(args[0]) != nil:
+		c := findCommand(args[0])
+		var fs *flag.FlagSet
+		if c.flags != nil {
+			fs = c.flags()
+		}
+		candidates = completeArgs(fs, args[1:], cur, c.complete)
+	default:
+		// Running a profile: the profile name may come before or after
+		// the flags
+		candidates = completeArgs(newRunFlags(new(runOptions)), args, cur, func(args []string, _ string) []string {
+			return positional(args, 0, profileNames())
+		})
+		if len(args) == 0 {
+			candidates = append(candidates, "--help", "--version")
+		}
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, cur) && !slices.Contains(matches, c) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+// flagValues complete the values of flags that take one.
+var flagValues = map[string]func(cur string) []string{
+	"events":      func(string) []string { return []string{"json"} },
+	"events-file": completeFiles,
+}
+
+// completeArgs completes cur, the argument after args, for a command with
+// the flags fs (nil for none). Positional arguments are completed by
+// positional, which is given the positional arguments before cur.
+func completeArgs(fs *flag.FlagSet, args []string, cur string, positional func(args []string, cur string) []string) []string {
+	var pos []string
+	flagsDone := fs == nil
+	for i := 0; i < len(args); i++ {
+		name, ok := flagName(args[i])
+		switch {
+		case flagsDone || !ok:
+			pos = append(pos, args[i])
+		case name == "":
+			flagsDone = true // "--"
+		case takesValue(fs, args[i]):
+			if i == len(args)-1 {
+				// cur is the value of this flag
+				if values := flagValues[name]; values != nil {
+					return values(cur)
+				}
+				return nil
+			}
+			i++
+		}
+	}
+
+	if !flagsDone && strings.HasPrefix(cur, "-") {
+		var names []string
+		fs.VisitAll(func(f *flag.Flag) { names = append(names, "--"+f.Name) })
+		return names
+	}
+	if positional == nil {
+		return nil
+	}
+	return positional(pos, cur)
+}
+
+// flagName returns the name of the flag arg, e.g. "branch" for
+// "--branch=x", and "" for "--". ok is false if arg is not a flag.
+func flagName(arg string) (name string, ok bool) {
+	if arg == "--" {
+		return "", true
+	}
+	if len(arg) < 2 || arg[0] != '-' {
+		return "", false
+	}
+	name = strings.TrimLeft(arg, "-")
+	name, _, _ = strings.Cut(name, "=")
+	return name, name != ""
+}
+
+// takesValue reports whether arg is a flag of fs whose value is the next
+// argument.
+func takesValue(fs *flag.FlagSet, arg string) bool {
+	name, _ := flagName(arg)
+	f := fs.Lookup(name)
+	if f == nil || strings.Contains(arg, "=") {
+		return false
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return !ok || !b.IsBoolFlag()
+}
+
+// profileNames returns the profiles of the current repository's merged
+// config, or nil if it cannot be loaded. Profiles named after a command
+// are left out, since aw runs the command instead.
+func profileNames() []string {
+	cfg, err := profile.Load()
+	if err != nil {
+		return nil
+	}
+	return slices.DeleteFunc(cfg.ProfileNames(), func(name string) bool {
+		return slices.Contains(profile.ReservedNames, name)
+	})
+}
+
+func completeWorktrees(args []string, _ string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+	infos, err := worktree.List(".")
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	return names
+}
+
+func completeConfig(args []string, cur string) []string {
+	switch {
+	case len(args) == 0:
+		return []string{"show", "validate", "schema"}
+	case len(args) == 1 && args[0] == "show":
+		return profileNames()
+	case len(args) == 1 && args[0] == "validate":
+		return completeFiles(cur)
+	}
+	return nil
+}
+
+// completeFiles returns the files and directories (with a trailing slash)
+// whose path starts with cur. Hidden ones are included only if cur names
+// them, e.g. ".a".
+func completeFiles(cur string) []string {
+	dir, prefix := filepath.Split(cur)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if e.IsDir() {
+			name += "/"
+		}
+		paths = append(paths, dir+name)
+	}
+	return paths
+}
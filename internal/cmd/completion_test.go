@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "-C", repo, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	// doctor is shadowed by `aw doctor`, so it is not offered as a profile
+	config := "profiles:\n  dev:\n    environment: host\n    launch: shell\n  doctor:\n    environment: host\n    launch: shell\n"
+	if err := os.WriteFile(filepath.Join(repo, ".agent-workspace.yml"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(repo, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"pro"}, []string{"profiles"}},
+		{[]string{"d"}, []string{"doctor", "default-dockerfile", "dev"}},
+		{[]string{"cl"}, []string{"claude"}}, // built-in profile
+		{[]string{"--v"}, []string{"--version"}},
+		{[]string{"dev", "--re"}, []string{"--resume"}},
+		{[]string{"dev", "--events", ""}, []string{"json"}},
+		{[]string{"--branch", "x", "d"}, []string{"dev"}},
+		{[]string{"--branch", "x", "doc"}, nil},
+		{[]string{"dev", "--branch", ""}, nil},
+		{[]string{"dev", ""}, nil},
+		{[]string{"doctor", "-"}, []string{"--json"}},
+		{[]string{"doctor", ""}, nil},
+		{[]string{"init", "--"}, []string{"--force", "--non-interactive"}},
+		{[]string{"config", ""}, []string{"show", "validate", "schema"}},
+		{[]string{"config", "show", "de"}, []string{"dev"}},
+		{[]string{"config", "validate", "do"}, []string{"docs/"}},
+		{[]string{"config", "validate", ".agent"}, []string{".agent-workspace.yml"}},
+		{[]string{"completion", "z"}, []string{"zsh"}},
+		{[]string{"help", "up"}, []string{"update"}},
+	}
+
+	for _, tt := range tests {
+		if got := complete(tt.words); !slices.Equal(got, tt.want) {
+			t.Errorf("complete(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	for _, shell := range completionShells {
+		if completionScripts[shell] == "" {
+			t.Errorf("no completion script for %s", shell)
+		}
+	}
+	if code := runCompletion([]string{"powershell"}); code != 2 {
+		t.Errorf("runCompletion(powershell) = %d, want 2", code)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	for _, c := range commands {
+		if c.summary == "" || c.run == nil {
+			t.Errorf("command %q has no summary or run function", c.name)
+		}
+		if c.flags != nil && c.flags().Name() != "aw "+c.name {
+			t.Errorf("command %q has the flags of %q", c.name, c.flags().Name())
+		}
+	}
+	if code := runHelp([]string{"doctor"}); code != 0 {
+		t.Errorf("runHelp(doctor) = %d, want 0", code)
+	}
+	if code := runHelp([]string{"nope"}); code != 2 {
+		t.Errorf("runHelp(nope) = %d, want 2", code)
+	}
+}
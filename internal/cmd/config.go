@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+const configUsage = `Usage: aw config show [profile]     print the merged config, with defaults filled in
+       aw config validate [file]   check a config file (default: the repository's .agent-workspace.yml)
+       aw config schema            print a JSON Schema for .agent-workspace.yml`
+
+// runConfig implements `aw config <show|validate|schema>`.
+func runConfig(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, configUsage)
+		return 2
+	}
+	switch args[0] {
+	case "show":
+		return runConfigShow(args[1:])
+	case "validate":
+		return runConfigValidate(args[1:])
+	case "schema":
+		return runConfigSchema(args[1:])
+	case "-h", "-help", "--help", "help":
+		fmt.Println(configUsage)
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "unknown config command: %q\n%s\n", args[0], configUsage)
+	return 2
+}
+
+func runConfigShow(args []string) int {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: aw config show [profile]")
+		return 2
+	}
+
+	cfg, err := profile.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+
+	name := ""
+	if len(args) == 1 {
+		name = args[0]
+		if _, ok := cfg.Profiles[name]; !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown profile %q\n\n", name)
+			printAvailableProfiles(cfg)
+			return 1
+		}
+	}
+
+	if err := showConfig(os.Stdout, cfg, name, resolveDefaultBase(cfg)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// showConfig writes cfg as YAML, with the defaults of unset fields filled
+// in; defaultBase is the base of worktree profiles that set none. If name is
+// not empty, only that profile is written.
+func showConfig(w io.Writer, cfg *profile.Config, name, defaultBase string) error {
+	if cfg.Source.IsBuiltin {
+		fmt.Fprintln(w, "# Source: built-in default (no .agent-workspace.yml found)")
+	} else {
+		fmt.Fprintf(w, "# Source: %s (merged with the built-in default)\n", cfg.Source.FilePath)
+	}
+
+	var doc any
+	if name != "" {
+		doc = map[string]profile.Profile{name: profile.WithDefaults(cfg.Profiles[name], defaultBase)}
+	} else {
+		shown := profile.Config{Default: cfg.Default, DefaultBase: cfg.DefaultBase, Hooks: cfg.Hooks, Profiles: make(map[string]profile.Profile)}
+		for n, p := range cfg.Profiles {
+			shown.Profiles[n] = profile.WithDefaults(p, defaultBase)
+		}
+		doc = shown
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	return enc.Close()
+}
+
+func runConfigValidate(args []string) int {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: aw config validate [file]")
+		return 2
+	}
+
+	path := ""
+	if len(args) == 1 {
+		path = args[0]
+	} else {
+		p, err := profile.ConfigPath()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v (pass the config file to validate)\n", err)
+			return 2
+		}
+		path = p
+	}
+
+	diags, err := profile.CheckFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	for _, d := range diags {
+		if d.Line == 0 {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, d.Message)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s:%s\n", path, d)
+	}
+	if len(diags) > 0 {
+		return 1
+	}
+	fmt.Printf("%s: OK\n", path)
+	return 0
+}
+
+func runConfigSchema(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: aw config schema")
+		return 2
+	}
+	out, err := json.MarshalIndent(profile.Schema(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(out))
+	return 0
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+func TestShowConfig(t *testing.T) {
+	cfg := &profile.Config{
+		Default: "dev",
+		Profiles: map[string]profile.Profile{
+			"dev": {
+				Worktree:    &profile.WorktreeConfig{Base: "origin/develop"},
+				Environment: profile.EnvironmentHost,
+				Launch:      profile.LaunchShell,
+			},
+			"plain": {Environment: profile.EnvironmentHost, Launch: profile.LaunchShell},
+			"fresh": {
+				Worktree:    &profile.WorktreeConfig{},
+				Environment: profile.EnvironmentHost,
+				Launch:      profile.LaunchShell,
+			},
+		},
+		Source: profile.ConfigSource{FilePath: "/repo/.agent-workspace.yml"},
+	}
+
+	var buf bytes.Buffer
+	if err := showConfig(&buf, cfg, "", "origin/main"); err != nil {
+		t.Fatalf("showConfig() error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "# Source: /repo/.agent-workspace.yml") {
+		t.Errorf("output does not start with the source:\n%s", buf.String())
+	}
+	shown, err := profile.Parse(buf.Bytes())
+	if err != nil {
+		t.Fatalf("output is not a config: %v\n%s", err, buf.String())
+	}
+	if shown.Default != "dev" || len(shown.Profiles) != 3 {
+		t.Errorf("shown = %+v", shown)
+	}
+	w := shown.Profiles["dev"].Worktree
+	if w == nil || w.Base != "origin/develop" || w.Fetch != profile.FetchAlways || w.Dir != "worktrees" {
+		t.Errorf("dev worktree = %+v, want defaults filled in", w)
+	}
+	if w := shown.Profiles["fresh"].Worktree; w == nil || w.Base != "origin/main" {
+		t.Errorf("fresh worktree = %+v, want the default base", w)
+	}
+
+	buf.Reset()
+	if err := showConfig(&buf, cfg, "plain", "origin/main"); err != nil {
+		t.Fatalf("showConfig() error: %v", err)
+	}
+	var single map[string]profile.Profile
+	if err := yaml.Unmarshal(buf.Bytes(), &single); err != nil {
+		t.Fatal(err)
+	}
+	if len(single) != 1 || single["plain"].Launch != profile.LaunchShell {
+		t.Errorf("single profile = %+v", single)
+	}
+}
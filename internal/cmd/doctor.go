@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hiragram/agent-workspace/internal/doctor"
+	"github.com/hiragram/agent-workspace/internal/profile"
+	"github.com/hiragram/agent-workspace/internal/stage"
+	"github.com/hiragram/agent-workspace/internal/worktree"
+)
+
+func newDoctorFlags(asJSON *bool) *flag.FlagSet {
+	fs := flag.NewFlagSet("aw doctor", flag.ContinueOnError)
+	fs.BoolVar(asJSON, "json", false, "print the results as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: aw doctor [--json]")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// runDoctor implements `aw doctor [--json]`. It exits with 1 if any check
+// fails.
+func runDoctor(args []string) int {
+	var asJSON bool
+	fs := newDoctorFlags(&asJSON)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %v\n", fs.Args())
+		fs.Usage()
+		return 2
+	}
+
+	results := doctor.Run(context.Background(), doctorEnv())
+
+	if asJSON {
+		out, err := json.MarshalIndent(struct {
+			OK     bool            `json:"ok"`
+			Checks []doctor.Result `json:"checks"`
+		}{!doctor.Failed(results), results}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(out))
+	} else if err := doctor.Report(os.Stdout, results); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if doctor.Failed(results) {
+		return 1
+	}
+	return 0
+}
+
+// doctorEnv describes the real system and the current repository.
+func doctorEnv() doctor.Env {
+	env := doctor.DefaultEnv()
+	env.VolumeName = stage.DefaultVolumeName
+	env.DictPath = worktree.DictPath()
+
+	if home, err := os.UserHomeDir(); err == nil {
+		env.ClaudeHome = filepath.Join(home, ".claude")
+		env.DataDir = filepath.Join(home, ".agent-workspace")
+	}
+	if v := os.Getenv("CLAUDE_HOME"); v != "" {
+		env.ClaudeHome = v
+	}
+
+	if path, err := profile.ConfigPath(); err == nil {
+		env.ConfigPath = path
+		env.RepoRoot = filepath.Dir(path)
+	}
+	if cfg, err := profile.Load(); err == nil {
+		env.Config = cfg
+	}
+	return env
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+	"github.com/hiragram/agent-workspace/internal/scaffold"
+)
+
+// initOptions holds the flags of `aw init`.
+type initOptions struct {
+	nonInteractive bool
+	force          bool
+}
+
+func newInitFlags(opts *initOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("aw init", flag.ContinueOnError)
+	fs.BoolVar(&opts.nonInteractive, "non-interactive", false, "use the detected settings without asking")
+	fs.BoolVar(&opts.force, "force", false, "overwrite an existing .agent-workspace.yml")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: aw init [--non-interactive] [--force]")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// runInit implements `aw init [--non-interactive] [--force]`: it writes a
+// .agent-workspace.yml tailored to the repository.
+func runInit(args []string) int {
+	var flags initOptions
+	fs := newInitFlags(&flags)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %v\n", fs.Args())
+		fs.Usage()
+		return 2
+	}
+
+	path, err := profile.ConfigPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	if _, err := os.Stat(path); err == nil && !flags.force {
+		if flags.nonInteractive {
+			fmt.Fprintf(os.Stderr, "Error: %s already exists (use --force to overwrite it)\n", path)
+			return 1
+		}
+		if !confirm(in, os.Stderr, fmt.Sprintf("%s already exists. Overwrite it?", path), false) {
+			return 1
+		}
+	}
+
+	_, dockerErr := exec.LookPath("docker")
+	project := scaffold.Detect(filepath.Dir(path))
+	opts := scaffold.DefaultOptions(project, dockerErr == nil)
+	if !flags.nonInteractive {
+		opts = askOptions(in, os.Stderr, opts)
+	}
+
+	data := scaffold.Render(project, opts)
+	if err := checkGenerated(data); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: generated config is invalid: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	fmt.Fprintln(os.Stderr, "Run `aw` to start the dev profile, or `aw profiles` to list them all.")
+	return 0
+}
+
+// askOptions asks for each setting, offering opts as the defaults.
+func askOptions(in *bufio.Reader, out io.Writer, opts scaffold.Options) scaffold.Options {
+	base := opts.Base
+	if base == "" {
+		base = remoteDefaultBase
+	}
+	if base = ask(in, out, "Base branch for new worktrees", base, nil); base == remoteDefaultBase {
+		base = ""
+	}
+	opts.Base = base
+	opts.Environment = profile.Environment(ask(in, out, "Run in (host, docker)", string(opts.Environment),
+		[]string{string(profile.EnvironmentHost), string(profile.EnvironmentDocker)}))
+	opts.Launch = profile.LaunchMode(ask(in, out, "Launch (claude, shell, zellij, tmux)", string(opts.Launch),
+		[]string{string(profile.LaunchClaude), string(profile.LaunchShell), string(profile.LaunchZellij), string(profile.LaunchTmux)}))
+
+	onCreate := opts.OnCreate
+	if onCreate == "" {
+		onCreate = "none"
+	}
+	if onCreate = ask(in, out, "Setup command for new worktrees", onCreate, nil); onCreate == "none" {
+		onCreate = ""
+	}
+	opts.OnCreate = onCreate
+
+	if len(opts.Copy) > 0 && !confirm(in, out, fmt.Sprintf("Copy %s into new worktrees?", strings.Join(opts.Copy, ", ")), true) {
+		opts.Copy = nil
+	}
+	return opts
+}
+
+// ask prompts for a value, returning def for an empty answer or at end of
+// input. If choices is not empty, it asks again until one of them is given.
+func ask(in *bufio.Reader, out io.Writer, question, def string, choices []string) string {
+	for {
+		fmt.Fprintf(out, "%s [%s]: ", question, def)
+		line, err := in.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			if err != nil {
+				fmt.Fprintln(out)
+			}
+			return def
+		}
+		if len(choices) == 0 || slices.Contains(choices, answer) {
+			return answer
+		}
+		fmt.Fprintf(out, "Please answer one of: %s\n", strings.Join(choices, ", "))
+		if err != nil {
+			return def
+		}
+	}
+}
+
+// confirm asks a yes/no question, returning def for an empty answer.
+func confirm(in *bufio.Reader, out io.Writer, question string, def bool) bool {
+	d := "n"
+	if def {
+		d = "y"
+	}
+	answer := ask(in, out, question+" (y/n)", d, []string{"y", "n", "yes", "no"})
+	return answer == "y" || answer == "yes"
+}
+
+// checkGenerated verifies that a generated config parses and validates.
+func checkGenerated(data []byte) error {
+	cfg, err := profile.Parse(data)
+	if err != nil {
+		return err
+	}
+	return profile.ValidateConfig(cfg)
+}
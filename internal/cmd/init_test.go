@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+	"github.com/hiragram/agent-workspace/internal/scaffold"
+)
+
+func TestAskOptions(t *testing.T) {
+	defaults := scaffold.Options{
+		Base:        "origin/main",
+		Environment: profile.EnvironmentDocker,
+		Launch:      profile.LaunchClaude,
+		OnCreate:    "npm ci",
+		Copy:        []string{".env.local"},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  scaffold.Options
+	}{
+		{"all defaults", "\n\n\n\n\n", defaults},
+		{"end of input", "", defaults},
+		{
+			name:  "answers",
+			input: "origin/develop\nhost\nscreen\nzellij\nnone\nn\n",
+			want: scaffold.Options{
+				Base:        "origin/develop",
+				Environment: profile.EnvironmentHost,
+				Launch:      profile.LaunchZellij,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := askOptions(bufio.NewReader(strings.NewReader(tt.input)), io.Discard, defaults)
+			if got.Base != tt.want.Base || got.Environment != tt.want.Environment || got.Launch != tt.want.Launch ||
+				got.OnCreate != tt.want.OnCreate || len(got.Copy) != len(tt.want.Copy) {
+				t.Errorf("askOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
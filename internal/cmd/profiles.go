@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+	"github.com/hiragram/agent-workspace/internal/worktree"
+)
+
+func newProfilesFlags(asJSON *bool) *flag.FlagSet {
+	fs := flag.NewFlagSet("aw profiles", flag.ContinueOnError)
+	fs.BoolVar(asJSON, "json", false, "print the merged profiles as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: aw profiles [--json]")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// runProfiles implements `aw profiles [--json]`.
+func runProfiles(args []string) int {
+	var asJSON bool
+	fs := newProfilesFlags(&asJSON)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %v\n", fs.Args())
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := profile.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+
+	if asJSON {
+		out, err := profilesJSON(cfg, resolveDefaultBase(cfg))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(out))
+		return 0
+	}
+
+	// Show config source
+	if cfg.Source.IsBuiltin {
+		fmt.Println("Source: built-in default (no .agent-workspace.yml found)")
+	} else {
+		fmt.Printf("Source: %s\n", cfg.Source.FilePath)
+	}
+	fmt.Println()
+
+	printAvailableProfiles(cfg)
+	return 0
+}
+
+// profilesOutput is the JSON document printed by `aw profiles --json`.
+type profilesOutput struct {
+	Source   sourceJSON    `json:"source"`
+	Default  string        `json:"default,omitempty"`
+	Profiles []profileJSON `json:"profiles"`
+}
+
+type sourceJSON struct {
+	Builtin bool   `json:"builtin"`
+	File    string `json:"file,omitempty"`
+}
+
+type profileJSON struct {
+	Name    string                   `json:"name"`
+	Default bool                     `json:"default"`
+	Layers  []profile.Layer          `json:"layers"`         // config layers defining the profile, lowest first
+	Fields  map[string]profile.Layer `json:"fields"`         // layer that set each field
+	Base    string                   `json:"base,omitempty"` // resolved worktree base, for worktree profiles
+	Valid   bool                     `json:"valid"`
+	Error   string                   `json:"error,omitempty"` // validation error, if not valid
+	Config  map[string]any           `json:"config"`          // merged profile, as in .agent-workspace.yml
+}
+
+// profilesJSON encodes the merged profiles of cfg, sorted by name.
+// defaultBase is the base of worktree profiles that set none.
+func profilesJSON(cfg *profile.Config, defaultBase string) ([]byte, error) {
+	out := profilesOutput{
+		Source:   sourceJSON{Builtin: cfg.Source.IsBuiltin, File: cfg.Source.FilePath},
+		Default:  cfg.Default,
+		Profiles: []profileJSON{},
+	}
+	for _, name := range cfg.ProfileNames() {
+		p := cfg.Profiles[name]
+		fields, err := profile.Fields(p)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", name, err)
+		}
+		origin := cfg.Origins[name]
+		pj := profileJSON{
+			Name:    name,
+			Default: name == cfg.Default,
+			Layers:  origin.Layers,
+			Fields:  origin.Fields,
+			Valid:   true,
+			Config:  fields,
+		}
+		if p.Worktree != nil {
+			pj.Base = p.Worktree.EffectiveBase(defaultBase)
+		}
+		if err := profile.Validate(p); err != nil {
+			pj.Valid = false
+			pj.Error = err.Error()
+		}
+		out.Profiles = append(out.Profiles, pj)
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// remoteDefaultBase is shown for the base of worktree profiles when it is
+// the remote's default branch and that is not known locally.
+const remoteDefaultBase = "(remote default)"
+
+// resolveDefaultBase returns the base of worktree profiles that set none:
+// the config's default-base, or the default branch recorded in the
+// repository. It returns "" outside a git repository, and if only the
+// remote knows its default branch: listing profiles must not wait for the
+// network, which only happens when a worktree is created.
+func resolveDefaultBase(cfg *profile.Config) string {
+	if cfg.DefaultBase != "" {
+		return cfg.DefaultBase
+	}
+	path, err := profile.ConfigPath()
+	if err != nil {
+		return ""
+	}
+	return worktree.LocalDefaultBase(filepath.Dir(path))
+}
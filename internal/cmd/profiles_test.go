@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+func TestProfilesJSON(t *testing.T) {
+	user, err := profile.Parse([]byte(`
+default: dev
+profiles:
+  worktree-zellij:
+    environment: host
+  dev:
+    worktree:
+      base: origin/develop
+    environment: host
+    launch: shell
+  broken:
+    environment: host
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	builtin := profile.Config{
+		Default: "worktree-zellij",
+		Profiles: map[string]profile.Profile{
+			"worktree-zellij": {
+				Worktree:    &profile.WorktreeConfig{},
+				Environment: profile.EnvironmentDocker,
+				Launch:      profile.LaunchZellij,
+			},
+		},
+	}
+	cfg := profile.MergeConfig(builtin, *user)
+	cfg.Source = profile.ConfigSource{FilePath: "/repo/.agent-workspace.yml"}
+
+	data, err := profilesJSON(&cfg, "upstream/trunk")
+	if err != nil {
+		t.Fatalf("profilesJSON() error: %v", err)
+	}
+
+	var out profilesOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+
+	if out.Source.File != "/repo/.agent-workspace.yml" || out.Source.Builtin {
+		t.Errorf("source = %+v", out.Source)
+	}
+	if out.Default != "dev" {
+		t.Errorf("default = %q, want dev", out.Default)
+	}
+
+	var names []string
+	for _, p := range out.Profiles {
+		names = append(names, p.Name)
+	}
+	if len(names) != 3 || names[0] != "broken" || names[1] != "dev" || names[2] != "worktree-zellij" {
+		t.Fatalf("profiles = %v, want sorted [broken dev worktree-zellij]", names)
+	}
+
+	broken, dev, zellij := out.Profiles[0], out.Profiles[1], out.Profiles[2]
+	if broken.Valid || broken.Error == "" {
+		t.Errorf("broken = %+v, want a validation error", broken)
+	}
+	if !dev.Valid || !dev.Default {
+		t.Errorf("dev = %+v, want valid default profile", dev)
+	}
+	if wt, ok := dev.Config["worktree"].(map[string]any); !ok || wt["base"] != "origin/develop" {
+		t.Errorf("dev config = %v", dev.Config)
+	}
+	if dev.Base != "origin/develop" || zellij.Base != "upstream/trunk" || broken.Base != "" {
+		t.Errorf("bases = %q, %q, %q; want origin/develop, upstream/trunk and none", dev.Base, zellij.Base, broken.Base)
+	}
+	if len(dev.Layers) != 1 || dev.Layers[0] != profile.LayerUser {
+		t.Errorf("dev layers = %v, want [user]", dev.Layers)
+	}
+
+	// Merged profile: environment overridden by the user, the rest builtin
+	if len(zellij.Layers) != 2 {
+		t.Errorf("worktree-zellij layers = %v, want [builtin user]", zellij.Layers)
+	}
+	if zellij.Config["environment"] != "host" {
+		t.Errorf("worktree-zellij environment = %v, want host", zellij.Config["environment"])
+	}
+	wantFields := map[string]profile.Layer{
+		"environment": profile.LayerUser,
+		"launch":      profile.LayerBuiltin,
+		"worktree":    profile.LayerBuiltin,
+	}
+	for field, layer := range wantFields {
+		if zellij.Fields[field] != layer {
+			t.Errorf("worktree-zellij fields[%s] = %q, want %q", field, zellij.Fields[field], layer)
+		}
+	}
+}
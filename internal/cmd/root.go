@@ -2,46 +2,54 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"os/exec"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/hiragram/agent-workspace/internal/image"
 	"github.com/hiragram/agent-workspace/internal/pipeline"
 	"github.com/hiragram/agent-workspace/internal/profile"
 	"github.com/hiragram/agent-workspace/internal/stage"
-	"github.com/hiragram/agent-workspace/internal/update"
 	"github.com/hiragram/agent-workspace/internal/version"
+	"github.com/hiragram/agent-workspace/internal/worktree"
 )
 
 // Run is the top-level entry point. Returns an exit code.
 func Run(args []string) int {
+	// The words being completed may include --version
+	if len(args) > 0 && args[0] == completeCommand {
+		return runComplete(args[1:])
+	}
+
 	if hasVersionFlag(args) {
 		fmt.Printf("aw %s\n", version.Version)
 		return 0
 	}
 
-	if len(args) > 0 && args[0] == "update" {
-		if err := update.Run(version.Version); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			return 1
+	if len(args) > 0 {
+		if c := findCommand(args[0]); c != nil {
+			return c.run(args[1:])
 		}
-		return 0
-	}
-
-	if len(args) > 0 && args[0] == "profiles" {
-		return runProfiles()
 	}
 
-	if len(args) > 0 && args[0] == "default-dockerfile" {
-		return runDefaultDockerfile()
+	opts, err := parseRunArgs(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
 	}
+	return runProfile(opts)
+}
 
-	// Determine profile name
-	profileName := ""
-	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
-		profileName = args[0]
-	}
+// runProfile loads the config and runs the pipeline for the selected profile.
+func runProfile(opts runOptions) int {
+	profileName := opts.profileName
 
 	// Load config
 	cfg, err := profile.Load()
@@ -73,12 +81,18 @@ func Run(args []string) int {
 		return 1
 	}
 
-	// Validate the selected profile
+	// Validate the selected profile, with the hooks shared by all profiles
+	p.Hooks = profile.CombineHooks(cfg.Hooks, p.Hooks)
 	if err := profile.Validate(p); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: invalid profile %q: %v\n", profileName, err)
 		return 1
 	}
 
+	if (opts.branch != "" || opts.ticket != "" || opts.pr != 0 || opts.resume) && p.Worktree == nil {
+		fmt.Fprintf(os.Stderr, "Error: --branch, --ticket, --pr and --resume require a profile with worktree (%q has none)\n", profileName)
+		return 1
+	}
+
 	// Build execution context
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -98,22 +112,69 @@ func Run(args []string) int {
 		HomeDir:     homeDir,
 		OrigWorkDir: workDir,
 		WorkDir:     workDir,
-	}
-
-	// Warn about on-end limitations
-	if p.Worktree != nil && p.Worktree.OnEnd != "" &&
-		p.Environment == profile.EnvironmentHost &&
-		p.Launch != profile.LaunchZellij {
-		fmt.Fprintf(os.Stderr, "Warning: on-end hook will not run with environment: host + launch: %s (process is replaced via exec)\n", p.Launch)
+		Branch:      opts.branch,
+		Ticket:      opts.ticket,
+		PR:          opts.pr,
+		Resume:      opts.resume,
+		ResumeName:  opts.resumeName,
+		DefaultBase: cfg.DefaultBase,
 	}
 
 	// Build pipeline stages
 	stages := buildStages(p)
 	pipe := pipeline.New(stages...)
+	pipe.HookRunner = stage.NewHookRunner()
+	pipe.KeepOnFailure = opts.keepOnFailure
+
+	reportTimings := func() {}
+	if opts.timings {
+		timings := &pipeline.Timings{}
+		reported := false
+		reportTimings = func() {
+			if !reported {
+				reported = true
+				timings.Report(os.Stderr)
+			}
+		}
+		// Report the setup time when the workspace is launched, since a
+		// host launch replaces aw
+		pipe.Observers = append(pipe.Observers, timings, pipeline.ObserverFunc(func(e pipeline.Event) {
+			if e.Type == pipeline.EventStart && e.Stage == "launch" && e.Step == "" {
+				reportTimings()
+			}
+		}))
+	}
+	if opts.events != "" {
+		w := os.Stderr
+		if opts.eventsFile != "" {
+			f, err := os.OpenFile(opts.eventsFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: opening events file: %v\n", err)
+				return 1
+			}
+			defer f.Close()
+			w = f
+		}
+		pipe.Observers = append(pipe.Observers, pipeline.NewJSONEventWriter(w))
+	}
+
+	// Cancel the pipeline on Ctrl-C, SIGTERM or SIGHUP instead of dying, so
+	// that deferred cleanups, rollbacks and the on-end hook still run
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
 
-	if err := pipe.Execute(context.Background(), ec); err != nil {
+	if err := pipe.Execute(ctx, ec); err != nil {
+		reportTimings()
 		runOnEndIfConfigured(ec)
+		var exitErr *exec.ExitError
+		if pipeline.IsNoRollback(err) && errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			// The session exited with an error; pass its status on
+			return exitErr.ExitCode()
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if errors.Is(err, pipeline.ErrInterrupted) {
+			return 130
+		}
 		return 1
 	}
 
@@ -121,6 +182,114 @@ func Run(args []string) int {
 	return 0
 }
 
+// runOptions holds the arguments for launching a profile.
+type runOptions struct {
+	profileName   string
+	branch        string
+	ticket        string
+	pr            int
+	resume        bool
+	resumeName    string // set by `aw open`
+	keepOnFailure bool
+	timings       bool
+	events        string // event stream format ("json"), empty for none
+	eventsFile    string // where to write events; default: stderr
+}
+
+// parseRunArgs parses `aw [profile] [flags]`. The profile name may appear
+// before or after the flags. Parse errors are reported to stderr.
+func parseRunArgs(args []string) (runOptions, error) {
+	var opts runOptions
+	fs := newRunFlags(&opts)
+
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		opts.profileName = args[0]
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	rest := fs.Args()
+	if opts.profileName == "" && len(rest) > 0 {
+		opts.profileName = rest[0]
+		rest = rest[1:]
+	}
+	if opts.eventsFile != "" && opts.events == "" {
+		opts.events = "json"
+	}
+	var err error
+	switch {
+	case len(rest) > 0:
+		err = fmt.Errorf("unexpected arguments: %s", strings.Join(rest, " "))
+	case opts.pr < 0:
+		err = fmt.Errorf("invalid pull request number: %d", opts.pr)
+	case opts.pr != 0 && opts.branch != "":
+		err = fmt.Errorf("--branch and --pr cannot be used together")
+	case opts.resume && (opts.branch != "" || opts.pr != 0 || opts.ticket != ""):
+		err = fmt.Errorf("--resume cannot be used with --branch, --pr or --ticket")
+	case opts.events != "" && opts.events != "json":
+		err = fmt.Errorf("unknown event format: %q (must be \"json\")", opts.events)
+	}
+	if err != nil {
+		fmt.Fprintf(fs.Output(), "%v\n", err)
+		fs.Usage()
+		return opts, err
+	}
+	return opts, nil
+}
+
+// newRunFlags returns the flags for running a profile, stored in opts.
+func newRunFlags(opts *runOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("aw", flag.ContinueOnError)
+	fs.StringVar(&opts.branch, "branch", "", "existing branch to check out, or name of the branch to create (overrides worktree.branch)")
+	fs.StringVar(&opts.ticket, "ticket", "", "ticket ID for {{ticket}} in worktree.branch")
+	fs.IntVar(&opts.pr, "pr", 0, "number of a GitHub pull request to check out in the worktree")
+	fs.BoolVar(&opts.resume, "resume", false, "reuse the most recently used worktree of the profile instead of creating one")
+	fs.BoolVar(&opts.keepOnFailure, "keep-on-failure", false, "keep the created worktree and branch if a later step fails")
+	fs.BoolVar(&opts.timings, "timings", false, "print how long each stage and step took before launching")
+	fs.StringVar(&opts.events, "events", "", "write pipeline events in the given format (json: one JSON object per line)")
+	fs.StringVar(&opts.eventsFile, "events-file", "", "file to write events to, e.g. /dev/fd/3 (default: stderr; implies --events json)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: aw [profile] [--branch <name> | --pr <number> | --resume] [--ticket <id>] [--keep-on-failure] [--timings] [--events json [--events-file <path>]]")
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nRun `aw help` for the list of commands.")
+	}
+	return fs
+}
+
+// runOpen implements `aw open <worktree-name>`: it resumes an aw-created
+// worktree with the profile it was created with.
+func runOpen(args []string) int {
+	if len(args) != 1 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintln(os.Stderr, "Usage: aw open <worktree-name>")
+		printWorktrees()
+		return 2
+	}
+
+	info, err := worktree.Find(".", args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if errors.Is(err, worktree.ErrNotFound) {
+			printWorktrees()
+		}
+		return 1
+	}
+	return runProfile(runOptions{profileName: info.Profile, resume: true, resumeName: args[0]})
+}
+
+// printWorktrees lists the aw-created worktrees of the current repository.
+func printWorktrees() {
+	infos, err := worktree.List(".")
+	if err != nil || len(infos) == 0 {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "\nWorktrees:")
+	for _, info := range infos {
+		fmt.Fprintf(os.Stderr, "  %s  (%s, last used %s)\n", info.Name(), info.Profile, info.LastUsedAt.Format("2006-01-02 15:04"))
+	}
+}
+
 func runOnEndIfConfigured(ec *pipeline.ExecutionContext) {
 	if ec.Profile.Worktree == nil || ec.Profile.Worktree.OnEnd == "" {
 		return
@@ -148,49 +317,49 @@ func buildStages(p profile.Profile) []pipeline.Stage {
 		stages = append(stages, stage.NewDockerStage())
 	}
 
-	// Stage 3: Env loading (conditional — only for Docker, where custom env vars are needed)
-	if p.Environment == profile.EnvironmentDocker {
-		stages = append(stages, &stage.EnvStage{})
+	// Stage 3: on-create hook in the container (conditional). Runs after
+	// the image is built and before env loading, which reads .aw-env.
+	if p.Worktree != nil && p.Worktree.OnCreateIn == profile.HookRunInContainer {
+		stages = append(stages, stage.NewOnCreateStage())
 	}
 
-	// Stage 4: Launch (always)
+	// Stage 4: Env loading (always)
+	stages = append(stages, &stage.EnvStage{})
+
+	// Stage 5: Launch (always)
 	stages = append(stages, &stage.LaunchStage{})
 
 	return stages
 }
 
-func runProfiles() int {
-	cfg, err := profile.Load()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-		return 1
-	}
-
-	// Show config source
-	if cfg.Source.IsBuiltin {
-		fmt.Println("Source: built-in default (no .agent-workspace.yml found)")
-	} else {
-		fmt.Printf("Source: %s\n", cfg.Source.FilePath)
-	}
-	fmt.Println()
-
-	printAvailableProfiles(cfg)
-	return 0
-}
-
 func printAvailableProfiles(cfg *profile.Config) {
 	fmt.Println("Available profiles:")
-	for name, p := range cfg.Profiles {
+	// Detecting the default branch runs git, so only do it for profiles
+	// that need it
+	defaultBase, resolved := "", false
+	for _, name := range cfg.ProfileNames() {
+		p := cfg.Profiles[name]
 		marker := "  "
 		if name == cfg.Default {
 			marker = "* "
 		}
 
 		desc := describeProfile(p)
+		if p.Worktree != nil {
+			if p.Worktree.Base == "" && !resolved {
+				defaultBase, resolved = resolveDefaultBase(cfg), true
+			}
+			base := p.Worktree.EffectiveBase(defaultBase)
+			if base == "" {
+				base = remoteDefaultBase
+			}
+			desc += ", from " + base
+		}
 		fmt.Printf("  %s%s  (%s)\n", marker, name, desc)
 	}
 	fmt.Println()
-	fmt.Println("Usage: aw <profile-name>")
+	fmt.Println("Usage: aw <profile-name> [--branch <name> | --pr <number> | --resume] [--ticket <id>]  (aw --help for all flags)")
+	fmt.Println("       aw open <worktree-name>")
 	if cfg.Default != "" {
 		fmt.Printf("       aw              (runs default: %s)\n", cfg.Default)
 	}
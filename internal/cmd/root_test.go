@@ -37,15 +37,18 @@ func TestBuildStages_WorktreeHostShell(t *testing.T) {
 	}
 	stages := buildStages(p)
 
-	// Should have WorktreeStage + LaunchStage = 2 stages
-	if len(stages) != 2 {
-		t.Fatalf("got %d stages, want 2", len(stages))
+	// Should have WorktreeStage + EnvStage + LaunchStage = 3 stages
+	if len(stages) != 3 {
+		t.Fatalf("got %d stages, want 3", len(stages))
 	}
 	if stages[0].Name() != "worktree" {
 		t.Errorf("stage[0] = %q, want 'worktree'", stages[0].Name())
 	}
-	if stages[1].Name() != "launch" {
-		t.Errorf("stage[1] = %q, want 'launch'", stages[1].Name())
+	if stages[1].Name() != "env" {
+		t.Errorf("stage[1] = %q, want 'env'", stages[1].Name())
+	}
+	if stages[2].Name() != "launch" {
+		t.Errorf("stage[2] = %q, want 'launch'", stages[2].Name())
 	}
 }
 
@@ -75,6 +78,28 @@ func TestBuildStages_WorktreeDockerZellij(t *testing.T) {
 	}
 }
 
+func TestBuildStages_OnCreateInContainer(t *testing.T) {
+	p := profile.Profile{
+		Worktree: &profile.WorktreeConfig{
+			OnCreate:   "pnpm install",
+			OnCreateIn: profile.HookRunInContainer,
+		},
+		Environment: profile.EnvironmentDocker,
+		Launch:      profile.LaunchClaude,
+	}
+	stages := buildStages(p)
+
+	want := []string{"worktree", "docker", "on-create", "env", "launch"}
+	if len(stages) != len(want) {
+		t.Fatalf("got %d stages, want %d", len(stages), len(want))
+	}
+	for i, name := range want {
+		if stages[i].Name() != name {
+			t.Errorf("stage[%d] = %q, want %q", i, stages[i].Name(), name)
+		}
+	}
+}
+
 func TestBuildStages_HostClaude(t *testing.T) {
 	p := profile.Profile{
 		Environment: profile.EnvironmentHost,
@@ -82,12 +107,15 @@ func TestBuildStages_HostClaude(t *testing.T) {
 	}
 	stages := buildStages(p)
 
-	// Should have LaunchStage only = 1 stage
-	if len(stages) != 1 {
-		t.Fatalf("got %d stages, want 1", len(stages))
+	// Should have EnvStage + LaunchStage = 2 stages
+	if len(stages) != 2 {
+		t.Fatalf("got %d stages, want 2", len(stages))
 	}
-	if stages[0].Name() != "launch" {
-		t.Errorf("stage[0] = %q, want 'launch'", stages[0].Name())
+	if stages[0].Name() != "env" {
+		t.Errorf("stage[0] = %q, want 'env'", stages[0].Name())
+	}
+	if stages[1].Name() != "launch" {
+		t.Errorf("stage[1] = %q, want 'launch'", stages[1].Name())
 	}
 }
 
@@ -167,3 +195,58 @@ func TestDescribeProfile(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRunArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want runOptions
+	}{
+		{"no args", nil, runOptions{}},
+		{"profile only", []string{"dev"}, runOptions{profileName: "dev"}},
+		{"profile then flags", []string{"dev", "--branch", "feat/x"}, runOptions{profileName: "dev", branch: "feat/x"}},
+		{"flags then profile", []string{"--ticket=PROJ-1", "dev"}, runOptions{profileName: "dev", ticket: "PROJ-1"}},
+		{"flags without profile", []string{"-branch", "fix"}, runOptions{branch: "fix"}},
+		{"pull request", []string{"review", "--pr", "42"}, runOptions{profileName: "review", pr: 42}},
+		{"resume", []string{"dev", "--resume"}, runOptions{profileName: "dev", resume: true}},
+		{"keep on failure", []string{"dev", "--keep-on-failure"}, runOptions{profileName: "dev", keepOnFailure: true}},
+		{"timings and events", []string{"dev", "--timings", "--events", "json"}, runOptions{profileName: "dev", timings: true, events: "json"}},
+		{"events file implies json", []string{"dev", "--events-file", "/dev/fd/3"}, runOptions{profileName: "dev", events: "json", eventsFile: "/dev/fd/3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRunArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseRunArgs() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseRunArgs(%v) = %+v, want %+v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRunArgs_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown flag", []string{"dev", "--nope"}},
+		{"missing value", []string{"dev", "--branch"}},
+		{"extra arguments", []string{"dev", "other"}},
+		{"invalid pr", []string{"dev", "--pr", "abc"}},
+		{"negative pr", []string{"dev", "--pr", "-1"}},
+		{"branch and pr", []string{"dev", "--pr", "1", "--branch", "x"}},
+		{"resume and branch", []string{"dev", "--resume", "--branch", "x"}},
+		{"unknown event format", []string{"dev", "--events", "xml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseRunArgs(tt.args); err == nil {
+				t.Errorf("parseRunArgs(%v) should return error", tt.args)
+			}
+		})
+	}
+}
@@ -14,6 +14,12 @@ var syncFiles = []string{"settings.json", "CLAUDE.md"}
 // syncDirs is the list of directories to sync from claudeHome.
 var syncDirs = []string{"hooks", "plugins", "commands", "agents"}
 
+// SyncSources returns the files and directories in claudeHome that are
+// synced, if they exist.
+func SyncSources() []string {
+	return append(append([]string{}, syncFiles...), syncDirs...)
+}
+
 // Syncer syncs host Claude settings to the container-side config directory.
 type Syncer interface {
 	SyncSettings(claudeHome, containerClaudeHome string) error
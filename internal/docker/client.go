@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"sort"
+
+	"github.com/hiragram/agent-workspace/internal/proc"
 )
 
 // Mount represents a Docker mount (bind mount or named volume).
@@ -22,6 +25,10 @@ type RunConfig struct {
 	EnvVars   map[string]string
 	WorkDir   string
 	Command   []string
+
+	// NonInteractive runs the container without a TTY or stdin (e.g. for
+	// hook commands) instead of interactively.
+	NonInteractive bool
 }
 
 // Client is the interface for Docker operations.
@@ -67,7 +74,7 @@ func (c *ShellClient) CheckAvailable() error {
 
 // Build builds a Docker image from the given build context directory.
 func (c *ShellClient) Build(ctx context.Context, imageName, contextDir string) error {
-	cmd := exec.CommandContext(ctx, c.dockerCmd(), "build", "-t", imageName, contextDir)
+	cmd := proc.Interruptible(exec.CommandContext(ctx, c.dockerCmd(), "build", "-t", imageName, contextDir))
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -82,12 +89,22 @@ func (c *ShellClient) VolumeCreate(ctx context.Context, volumeName string) error
 }
 
 // BuildRunArgs constructs the docker CLI arguments for a RunConfig.
-// This is exported for testing.
+// Environment variables are passed by name only, so that their values (e.g.
+// tokens from env-passthrough) do not show up in the process list; the
+// docker CLI must run with the environment from RunEnv.
 func BuildRunArgs(config RunConfig) []string {
 	args := []string{"run", "-it", "--rm"}
+	if config.NonInteractive {
+		args = []string{"run", "--rm"}
+	}
 
-	for key, val := range config.EnvVars {
-		args = append(args, "-e", fmt.Sprintf("%s=%s", key, val))
+	keys := make([]string, 0, len(config.EnvVars))
+	for key := range config.EnvVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-e", key)
 	}
 
 	for _, m := range config.Mounts {
@@ -108,12 +125,27 @@ func BuildRunArgs(config RunConfig) []string {
 	return args
 }
 
-// Run runs a Docker container interactively with the given RunConfig.
+// RunEnv returns the environment for the docker CLI running config: the
+// current environment with config.EnvVars added.
+func RunEnv(config RunConfig) []string {
+	env := os.Environ()
+	for key, val := range config.EnvVars {
+		env = append(env, key+"="+val)
+	}
+	return env
+}
+
+// Run runs a Docker container with the given RunConfig. If ctx is canceled,
+// the docker CLI is interrupted, which stops and removes the container.
 func (c *ShellClient) Run(ctx context.Context, config RunConfig) error {
 	args := BuildRunArgs(config)
-	cmd := exec.CommandContext(ctx, c.dockerCmd(), args...)
-	cmd.Stdin = os.Stdin
+	cmd := proc.Interruptible(exec.CommandContext(ctx, c.dockerCmd(), args...))
+	cmd.Env = RunEnv(config)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	if config.NonInteractive {
+		return cmd.Run()
+	}
+	cmd.Stdin = os.Stdin
+	return proc.RunForeground(cmd)
 }
@@ -1,6 +1,7 @@
 package docker
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -88,16 +89,23 @@ func TestRunConfigBuildArgs(t *testing.T) {
 		t.Errorf("expected args to contain image name 'test-image', got %v", args)
 	}
 
-	// Should contain -e FOO=bar
+	// Should contain -e FOO, with the value only in the environment
 	foundEnv := false
 	for i, a := range args {
-		if a == "-e" && i+1 < len(args) && args[i+1] == "FOO=bar" {
+		if a == "-e" && i+1 < len(args) && args[i+1] == "FOO" {
 			foundEnv = true
 			break
 		}
+		if strings.Contains(a, "bar") {
+			t.Errorf("expected args not to contain the value of FOO, got %v", args)
+		}
 	}
 	if !foundEnv {
-		t.Errorf("expected args to contain -e FOO=bar, got %v", args)
+		t.Errorf("expected args to contain -e FOO, got %v", args)
+	}
+	env := RunEnv(config)
+	if env[len(env)-1] != "FOO=bar" {
+		t.Errorf("expected RunEnv to end with FOO=bar, got %v", env[len(env)-1])
 	}
 
 	// Should contain -v with read-only mount
@@ -160,3 +168,22 @@ func TestBuildRunArgsNoOptionalFields(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildRunArgsNonInteractive(t *testing.T) {
+	config := RunConfig{
+		ImageName:      "test-image",
+		Command:        []string{"sh", "-c", "make"},
+		NonInteractive: true,
+	}
+
+	args := BuildRunArgs(config)
+
+	if args[0] != "run" || args[1] != "--rm" {
+		t.Errorf("expected args to start with [run --rm], got %v", args[:2])
+	}
+	for _, a := range args {
+		if a == "-it" {
+			t.Error("expected no -it for a non-interactive run")
+		}
+	}
+}
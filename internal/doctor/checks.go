@@ -0,0 +1,303 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hiragram/agent-workspace/internal/config"
+	"github.com/hiragram/agent-workspace/internal/profile"
+	"github.com/hiragram/agent-workspace/internal/worktree"
+)
+
+// installHints are install commands for the required tools.
+var installHints = map[string]worktree.Dependency{
+	"git":    {Name: "git", MacOSHint: "xcode-select --install (or brew install git)", LinuxHint: "sudo apt install git"},
+	"docker": {Name: "docker", MacOSHint: "brew install --cask docker, then start Docker Desktop", LinuxHint: "install Docker Engine: https://docs.docker.com/engine/install/"},
+	"zellij": {Name: "zellij", MacOSHint: "brew install zellij", LinuxHint: "cargo install --locked zellij (or download a release: https://zellij.dev/documentation/installation)"},
+	"tmux":   {Name: "tmux", MacOSHint: "brew install tmux", LinuxHint: "sudo apt install tmux"},
+}
+
+// versionArgs are the arguments that make a terminal multiplexer print its
+// version.
+var versionArgs = map[string]string{
+	"zellij": "--version",
+	"tmux":   "-V",
+}
+
+// Free disk space below which new worktrees and images are likely to fail.
+const (
+	minFreeSpace  = 1 << 30 // 1 GiB
+	warnFreeSpace = 5 << 30 // 5 GiB
+)
+
+func checkGit(ctx context.Context, env Env) []Result {
+	r := Result{Name: "git"}
+	if _, err := env.LookPath("git"); err != nil {
+		r.Status, r.Message = StatusFail, "not installed or not in PATH"
+		r.Fix = installHints["git"].InstallHint(env.GOOS)
+		return []Result{r}
+	}
+	out, err := output(ctx, env, "git", "--version")
+	if err != nil {
+		r.Status, r.Message = StatusFail, fmt.Sprintf("git --version failed: %s", out)
+		return []Result{r}
+	}
+	r.Status, r.Message = StatusOK, out
+	return []Result{r}
+}
+
+func checkDocker(ctx context.Context, env Env) []Result {
+	users := profilesUsing(env.Config, func(p profile.Profile) bool { return p.Environment == profile.EnvironmentDocker })
+	missing := missingStatus(env.Config, users)
+
+	r := Result{Name: "docker"}
+	if _, err := env.LookPath("docker"); err != nil {
+		r.Status = missing
+		if _, err := env.LookPath("podman"); err == nil {
+			r.Message = "podman is installed, but aw runs the docker command"
+			r.Fix = `install podman-docker, or link docker to podman: ln -s "$(command -v podman)" /usr/local/bin/docker`
+		} else {
+			r.Message = "not installed or not in PATH" + neededBy(users)
+			r.Fix = installHints["docker"].InstallHint(env.GOOS)
+		}
+		return []Result{r}
+	}
+
+	out, err := output(ctx, env, "docker", "info", "--format", "{{.ServerVersion}}")
+	if err != nil {
+		r.Status = missing
+		if strings.Contains(out, "permission denied") {
+			r.Message = "permission denied connecting to the docker daemon"
+			r.Fix = "add yourself to the docker group: sudo usermod -aG docker $USER (then log in again)"
+		} else {
+			r.Message = "docker daemon is not running"
+			r.Fix = "sudo systemctl start docker"
+			if env.GOOS == "darwin" {
+				r.Fix = "start Docker Desktop: open -a Docker"
+			}
+		}
+		return []Result{r}
+	}
+	r.Status, r.Message = StatusOK, "daemon running (server "+out+")"
+	return []Result{r, checkVolume(ctx, env)}
+}
+
+// checkVolume checks the volume that holds the container's home directory.
+func checkVolume(ctx context.Context, env Env) Result {
+	r := Result{Name: "docker volume"}
+	out, err := output(ctx, env, "docker", "volume", "inspect", "--format", "{{.Driver}}", env.VolumeName)
+	switch {
+	case err == nil:
+		r.Status, r.Message = StatusOK, fmt.Sprintf("%s (%s driver)", env.VolumeName, out)
+	case strings.Contains(strings.ToLower(out), "no such volume"):
+		r.Status, r.Message = StatusOK, env.VolumeName+" will be created on the first docker run"
+	default:
+		r.Status, r.Message = StatusWarn, fmt.Sprintf("cannot inspect %s: %s", env.VolumeName, out)
+		r.Fix = "if runs keep failing, remove it (losing container state): docker volume rm " + env.VolumeName
+	}
+	return r
+}
+
+// checkMultiplexer returns the check of the terminal multiplexer that the
+// launch mode runs.
+func checkMultiplexer(mode profile.LaunchMode) func(context.Context, Env) []Result {
+	name := mode.Multiplexer()
+	return func(ctx context.Context, env Env) []Result {
+		users := profilesUsing(env.Config, func(p profile.Profile) bool { return p.Launch == mode })
+		r := Result{Name: name}
+		if _, err := env.LookPath(name); err != nil {
+			r.Status, r.Message = missingStatus(env.Config, users), "not installed"+neededBy(users)
+			if env.Config != nil && len(users) == 0 {
+				r.Message = "not installed (no profile uses launch: " + name + ")"
+			}
+			r.Fix = installHints[name].InstallHint(env.GOOS)
+			return []Result{r}
+		}
+		out, err := output(ctx, env, name, versionArgs[name])
+		if err != nil {
+			r.Status, r.Message = StatusWarn, fmt.Sprintf("%s %s failed: %s", name, versionArgs[name], out)
+			return []Result{r}
+		}
+		r.Status, r.Message = StatusOK, out
+		return []Result{r}
+	}
+}
+
+func checkDictionary(_ context.Context, env Env) []Result {
+	r := Result{Name: "dictionary"}
+	if _, err := os.Stat(env.DictPath); err != nil {
+		r.Status = StatusWarn
+		r.Message = env.DictPath + " not found; random branch names use the built-in word list"
+		if env.GOOS != "darwin" {
+			r.Fix = "sudo apt install wamerican (or your distribution's words package)"
+		}
+		return []Result{r}
+	}
+	r.Status, r.Message = StatusOK, env.DictPath
+	return []Result{r}
+}
+
+func checkOptionalTools(_ context.Context, env Env) []Result {
+	var results []Result
+	for _, dep := range worktree.OptionalDeps {
+		r := Result{Name: dep.Name + " (optional)"}
+		if path, err := env.LookPath(dep.Name); err == nil {
+			r.Status, r.Message = StatusOK, path
+		} else {
+			r.Status, r.Message = StatusWarn, "not installed; used by the zellij and tmux helper scripts"
+			r.Fix = dep.InstallHint(env.GOOS)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// checkClaudeHome checks the host Claude settings that are synced into
+// containers.
+func checkClaudeHome(_ context.Context, env Env) []Result {
+	r := Result{Name: "claude settings"}
+	if _, err := os.Stat(env.ClaudeHome); err != nil {
+		r.Status = StatusWarn
+		r.Message = env.ClaudeHome + " not found; no settings are synced into containers"
+		r.Fix = "run claude on the host once to create it, or set CLAUDE_HOME"
+		return []Result{r}
+	}
+
+	var found []string
+	for _, name := range config.SyncSources() {
+		path := filepath.Join(env.ClaudeHome, name)
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err := readable(path); err != nil {
+			r.Status, r.Message = StatusFail, fmt.Sprintf("cannot read %s: %v", path, err)
+			r.Fix = "fix the permissions: chmod -R u+rX " + path
+			return []Result{r}
+		}
+		found = append(found, name)
+	}
+	if len(found) == 0 {
+		r.Status, r.Message = StatusOK, env.ClaudeHome+" (nothing to sync)"
+		return []Result{r}
+	}
+	r.Status, r.Message = StatusOK, fmt.Sprintf("%s (syncing %s)", env.ClaudeHome, strings.Join(found, ", "))
+	return []Result{r}
+}
+
+func checkDiskSpace(_ context.Context, env Env) []Result {
+	var results []Result
+	for _, path := range []string{env.RepoRoot, env.DataDir} {
+		if path == "" {
+			continue
+		}
+		r := Result{Name: "disk space"}
+		free, err := env.FreeSpace(path)
+		switch {
+		case err != nil:
+			r.Status, r.Message = StatusWarn, fmt.Sprintf("cannot check %s: %v", path, err)
+		case free < minFreeSpace:
+			r.Status, r.Message = StatusFail, fmt.Sprintf("%s free at %s", formatBytes(free), path)
+			r.Fix = "free up space, e.g. remove old worktrees (git worktree remove) and images (docker image prune)"
+		case free < warnFreeSpace:
+			r.Status, r.Message = StatusWarn, fmt.Sprintf("%s free at %s", formatBytes(free), path)
+			r.Fix = "free up space, e.g. remove old worktrees (git worktree remove) and images (docker image prune)"
+		default:
+			r.Status, r.Message = StatusOK, fmt.Sprintf("%s free at %s", formatBytes(free), path)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func checkConfig(_ context.Context, env Env) []Result {
+	r := Result{Name: "config"}
+	if env.ConfigPath == "" {
+		r.Status, r.Message = StatusOK, "not in a git repository; using the built-in default"
+		return []Result{r}
+	}
+	diags, err := profile.CheckFile(env.ConfigPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		r.Status, r.Message = StatusOK, "no .agent-workspace.yml; using the built-in default (aw init creates one)"
+		return []Result{r}
+	}
+	if err != nil {
+		r.Status, r.Message = StatusFail, err.Error()
+		return []Result{r}
+	}
+	if len(diags) > 0 {
+		r.Status = StatusFail
+		r.Message = fmt.Sprintf("%s:%s", env.ConfigPath, diags[0])
+		if len(diags) > 1 {
+			r.Message += fmt.Sprintf(" (and %d more)", len(diags)-1)
+		}
+		r.Fix = "run aw config validate to see all errors"
+		return []Result{r}
+	}
+	r.Status, r.Message = StatusOK, env.ConfigPath
+	return []Result{r}
+}
+
+// output runs a command with a timeout and returns its trimmed output.
+func output(ctx context.Context, env Env, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	out, err := env.Output(ctx, name, args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+// profilesUsing returns the sorted names of the profiles of cfg for which
+// uses returns true.
+func profilesUsing(cfg *profile.Config, uses func(profile.Profile) bool) []string {
+	if cfg == nil {
+		return nil
+	}
+	var names []string
+	for _, name := range cfg.ProfileNames() {
+		if uses(cfg.Profiles[name]) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// missingStatus returns the status of a missing tool that the profiles
+// users need: a failure if the default profile needs it (or the config is
+// unknown), a warning if other profiles do.
+func missingStatus(cfg *profile.Config, users []string) Status {
+	switch {
+	case cfg == nil || slices.Contains(users, cfg.Default):
+		return StatusFail
+	case len(users) > 0:
+		return StatusWarn
+	}
+	return StatusOK
+}
+
+func neededBy(profiles []string) string {
+	if len(profiles) == 0 {
+		return ""
+	}
+	return "; needed by profiles: " + strings.Join(profiles, ", ")
+}
+
+func readable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func formatBytes(n uint64) string {
+	const gib = 1 << 30
+	if n >= gib {
+		return fmt.Sprintf("%.1f GiB", float64(n)/gib)
+	}
+	return fmt.Sprintf("%d MiB", n>>20)
+}
@@ -0,0 +1,154 @@
+// Package doctor checks the environment aw runs in and suggests fixes for
+// what is missing or broken.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn" // aw works, but with reduced functionality
+	StatusFail Status = "fail" // aw (or some profile) will not work
+)
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"` // what to do about a warning or failure
+}
+
+// Env is what the checks inspect. The function fields allow tests to fake
+// the system.
+type Env struct {
+	GOOS       string
+	RepoRoot   string          // empty outside a git repository
+	ConfigPath string          // empty outside a git repository
+	Config     *profile.Config // nil if it could not be loaded
+	ClaudeHome string          // host Claude settings synced into containers
+	DataDir    string          // where aw keeps container state, e.g. ~/.agent-workspace
+	VolumeName string
+	DictPath   string
+
+	LookPath  func(file string) (string, error)
+	Output    func(ctx context.Context, name string, args ...string) ([]byte, error)
+	FreeSpace func(path string) (uint64, error) // bytes available to the user
+}
+
+// DefaultEnv returns an Env that inspects the real system. The path and
+// config fields are left for the caller to fill in.
+func DefaultEnv() Env {
+	return Env{
+		GOOS:     runtime.GOOS,
+		LookPath: exec.LookPath,
+		Output: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return exec.CommandContext(ctx, name, args...).CombinedOutput()
+		},
+		FreeSpace: freeSpace,
+	}
+}
+
+// commandTimeout bounds each external command, e.g. `docker info` against
+// a hung daemon.
+const commandTimeout = 10 * time.Second
+
+// Run runs all checks in order.
+func Run(ctx context.Context, env Env) []Result {
+	checks := []func(context.Context, Env) []Result{
+		checkGit,
+		checkDocker,
+		checkMultiplexer(profile.LaunchZellij),
+		checkMultiplexer(profile.LaunchTmux),
+		checkDictionary,
+		checkOptionalTools,
+		checkClaudeHome,
+		checkDiskSpace,
+		checkConfig,
+	}
+	var results []Result
+	for _, check := range checks {
+		results = append(results, check(ctx, env)...)
+	}
+	return results
+}
+
+// Failed reports whether any result is a failure.
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Report writes results as a table, with fixes below failing checks, and
+// a summary line.
+func Report(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	var warnings, failures int
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", marker(r.Status), r.Name, r.Message)
+		if r.Fix != "" && r.Status != StatusOK {
+			fmt.Fprintf(tw, "\t\t-> %s\n", r.Fix)
+		}
+		switch r.Status {
+		case StatusWarn:
+			warnings++
+		case StatusFail:
+			failures++
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if failures == 0 && warnings == 0 {
+		_, err := fmt.Fprintln(w, "\nEverything looks good.")
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d problem(s), %d warning(s).\n", failures, warnings)
+	return err
+}
+
+func marker(s Status) string {
+	switch s {
+	case StatusWarn:
+		return "[warn]"
+	case StatusFail:
+		return "[FAIL]"
+	}
+	return "[ok]"
+}
+
+func freeSpace(path string) (uint64, error) {
+	// Measure the nearest existing directory, e.g. for a worktrees
+	// directory that has not been created yet
+	for {
+		if _, err := os.Stat(path); err == nil || filepath.Dir(path) == path {
+			break
+		}
+		path = filepath.Dir(path)
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,289 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+// fakeEnv returns an Env in which everything is installed and working.
+// Commands are looked up in installed and answered from outputs, keyed by
+// the command line; a missing output fails the command.
+func fakeEnv(t *testing.T, installed []string, outputs map[string]string) Env {
+	t.Helper()
+	dir := t.TempDir()
+	claudeHome := filepath.Join(dir, "claude")
+	if err := os.MkdirAll(filepath.Join(claudeHome, "hooks"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(claudeHome, "settings.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dict := filepath.Join(dir, "words")
+	if err := os.WriteFile(dict, []byte("apple\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, ".agent-workspace.yml")
+	if err := os.WriteFile(configPath, []byte("default: dev\nprofiles:\n  dev:\n    environment: docker\n    launch: zellij\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return Env{
+		GOOS:       "linux",
+		RepoRoot:   dir,
+		ConfigPath: configPath,
+		Config: &profile.Config{
+			Default: "dev",
+			Profiles: map[string]profile.Profile{
+				"dev":   {Environment: profile.EnvironmentDocker, Launch: profile.LaunchZellij},
+				"plain": {Environment: profile.EnvironmentHost, Launch: profile.LaunchShell},
+			},
+		},
+		ClaudeHome: claudeHome,
+		DataDir:    dir,
+		VolumeName: "claude-code-local",
+		DictPath:   dict,
+		LookPath: func(file string) (string, error) {
+			for _, name := range installed {
+				if name == file {
+					return "/usr/bin/" + file, nil
+				}
+			}
+			return "", errors.New("not found")
+		},
+		Output: func(_ context.Context, name string, args ...string) ([]byte, error) {
+			line := strings.Join(append([]string{name}, args...), " ")
+			out, ok := outputs[line]
+			if !ok {
+				return []byte("error: " + line), errors.New("exit status 1")
+			}
+			return []byte(out + "\n"), nil
+		},
+		FreeSpace: func(string) (uint64, error) { return 100 << 30, nil },
+	}
+}
+
+var allInstalled = []string{"git", "docker", "zellij", "tmux", "fswatch", "fzf", "delta", "glow", "gh"}
+
+var workingOutputs = map[string]string{
+	"git --version": "git version 2.45.0",
+	"docker info --format {{.ServerVersion}}":                      "27.0.1",
+	"docker volume inspect --format {{.Driver}} claude-code-local": "local",
+	"zellij --version": "zellij 0.40.1",
+	"tmux -V":          "tmux 3.4",
+}
+
+func result(t *testing.T, results []Result, name string) Result {
+	t.Helper()
+	for _, r := range results {
+		if r.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("no %q result in %+v", name, results)
+	return Result{}
+}
+
+func TestRun_AllGood(t *testing.T) {
+	results := Run(context.Background(), fakeEnv(t, allInstalled, workingOutputs))
+	for _, r := range results {
+		if r.Status != StatusOK {
+			t.Errorf("%s: %s %q", r.Name, r.Status, r.Message)
+		}
+	}
+	if Failed(results) {
+		t.Error("Failed() = true")
+	}
+	if got := result(t, results, "claude settings").Message; !strings.Contains(got, "syncing settings.json, hooks") {
+		t.Errorf("claude settings = %q", got)
+	}
+
+	var buf bytes.Buffer
+	if err := Report(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "[ok]") || !strings.Contains(buf.String(), "Everything looks good.") {
+		t.Errorf("Report() =\n%s", buf.String())
+	}
+}
+
+func TestRun_Problems(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(env *Env, outputs map[string]string)
+		installed  []string
+		check      string
+		wantStatus Status
+		wantMsg    string
+		wantFix    string
+	}{
+		{
+			name:       "git missing",
+			installed:  []string{"docker", "zellij"},
+			check:      "git",
+			wantStatus: StatusFail,
+			wantFix:    "apt install git",
+		},
+		{
+			name:       "podman instead of docker",
+			installed:  []string{"git", "podman", "zellij"},
+			check:      "docker",
+			wantStatus: StatusFail,
+			wantMsg:    "podman is installed",
+			wantFix:    "podman-docker",
+		},
+		{
+			name: "docker not needed by the default profile",
+			modify: func(env *Env, _ map[string]string) {
+				env.Config.Default = "plain"
+			},
+			installed:  []string{"git", "zellij"},
+			check:      "docker",
+			wantStatus: StatusWarn,
+			wantMsg:    "needed by profiles: dev",
+		},
+		{
+			name: "daemon not running on macOS",
+			modify: func(env *Env, outputs map[string]string) {
+				env.GOOS = "darwin"
+				delete(outputs, "docker info --format {{.ServerVersion}}")
+			},
+			installed:  allInstalled,
+			check:      "docker",
+			wantStatus: StatusFail,
+			wantMsg:    "daemon is not running",
+			wantFix:    "open -a Docker",
+		},
+		{
+			name: "volume not created yet",
+			modify: func(env *Env, _ map[string]string) {
+				output := env.Output
+				env.Output = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+					if len(args) > 0 && args[0] == "volume" {
+						return []byte("Error response from daemon: get claude-code-local: no such volume"), errors.New("exit status 1")
+					}
+					return output(ctx, name, args...)
+				}
+			},
+			installed:  allInstalled,
+			check:      "docker volume",
+			wantStatus: StatusOK,
+		},
+		{
+			name:       "zellij missing for the default profile",
+			installed:  []string{"git", "docker"},
+			check:      "zellij",
+			wantStatus: StatusFail,
+			wantMsg:    "needed by profiles: dev",
+			wantFix:    "cargo install --locked zellij",
+		},
+		{
+			name: "tmux missing for another profile",
+			modify: func(env *Env, _ map[string]string) {
+				env.Config.Profiles["pair"] = profile.Profile{Environment: profile.EnvironmentHost, Launch: profile.LaunchTmux}
+			},
+			installed:  []string{"git", "docker", "zellij"},
+			check:      "tmux",
+			wantStatus: StatusWarn,
+			wantMsg:    "needed by profiles: pair",
+			wantFix:    "apt install tmux",
+		},
+		{
+			name:       "tmux not used",
+			installed:  []string{"git", "docker", "zellij"},
+			check:      "tmux",
+			wantStatus: StatusOK,
+			wantMsg:    "no profile uses launch: tmux",
+		},
+		{
+			name: "dictionary missing",
+			modify: func(env *Env, _ map[string]string) {
+				env.DictPath = filepath.Join(t.TempDir(), "missing")
+			},
+			installed:  allInstalled,
+			check:      "dictionary",
+			wantStatus: StatusWarn,
+			wantMsg:    "built-in word list",
+		},
+		{
+			name:       "optional tool missing",
+			installed:  []string{"git", "docker", "zellij"},
+			check:      "fzf (optional)",
+			wantStatus: StatusWarn,
+			wantFix:    "apt install fzf",
+		},
+		{
+			name: "claude home missing",
+			modify: func(env *Env, _ map[string]string) {
+				env.ClaudeHome = filepath.Join(t.TempDir(), "missing")
+			},
+			installed:  allInstalled,
+			check:      "claude settings",
+			wantStatus: StatusWarn,
+			wantFix:    "CLAUDE_HOME",
+		},
+		{
+			name: "low disk space",
+			modify: func(env *Env, _ map[string]string) {
+				env.DataDir = ""
+				env.FreeSpace = func(string) (uint64, error) { return 300 << 20, nil }
+			},
+			installed:  allInstalled,
+			check:      "disk space",
+			wantStatus: StatusFail,
+			wantMsg:    "300 MiB free",
+		},
+		{
+			name: "invalid config",
+			modify: func(env *Env, _ map[string]string) {
+				if err := os.WriteFile(env.ConfigPath, []byte("profiles:\n  dev:\n    launch: tmux\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			installed:  allInstalled,
+			check:      "config",
+			wantStatus: StatusFail,
+			wantMsg:    `.agent-workspace.yml:3:5: profile "dev": environment is required`,
+			wantFix:    "aw config validate",
+		},
+		{
+			name: "no config file",
+			modify: func(env *Env, _ map[string]string) {
+				env.ConfigPath = filepath.Join(t.TempDir(), ".agent-workspace.yml")
+			},
+			installed:  allInstalled,
+			check:      "config",
+			wantStatus: StatusOK,
+			wantMsg:    "built-in default",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputs := make(map[string]string)
+			for k, v := range workingOutputs {
+				outputs[k] = v
+			}
+			env := fakeEnv(t, tt.installed, outputs)
+			if tt.modify != nil {
+				tt.modify(&env, outputs)
+			}
+			r := result(t, Run(context.Background(), env), tt.check)
+			if r.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s (%q)", r.Status, tt.wantStatus, r.Message)
+			}
+			if !strings.Contains(r.Message, tt.wantMsg) {
+				t.Errorf("message = %q, want it to contain %q", r.Message, tt.wantMsg)
+			}
+			if !strings.Contains(r.Fix, tt.wantFix) {
+				t.Errorf("fix = %q, want it to contain %q", r.Fix, tt.wantFix)
+			}
+		})
+	}
+}
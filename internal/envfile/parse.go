@@ -1,68 +1,336 @@
 package envfile
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 )
 
-// Parse reads KEY=VALUE pairs from the given reader.
-// Lines starting with # are comments. Empty lines are ignored.
-// Values may optionally be wrapped in double quotes (quotes are stripped).
-// No shell variable expansion is performed.
+// Parse reads KEY=VALUE pairs in dotenv format from the given reader.
+//
+// Supported syntax:
+//   - Empty lines and lines starting with # are ignored.
+//   - Keys may be prefixed with "export ".
+//   - Unquoted values are trimmed; a # preceded by whitespace starts a comment.
+//   - Single-quoted values are taken literally.
+//   - Double-quoted values support the escapes \n, \r, \t, \", \\ and \$.
+//   - Quoted values may span multiple lines.
+//   - ${VAR} and $VAR in unquoted and double-quoted values are replaced with
+//     the value of a key defined earlier in the file, or else of the host
+//     environment variable (empty if neither is set).
 func Parse(r io.Reader) (map[string]string, error) {
-	env := make(map[string]string)
-	scanner := bufio.NewScanner(r)
-	lineNum := 0
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading env file: %w", err)
+	}
 
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
+	p := &parser{src: string(data), line: 1, env: make(map[string]string)}
+	for !p.eof() {
+		if err := p.parseLine(); err != nil {
+			return nil, err
+		}
+	}
+	return p.env, nil
+}
 
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+// ParseFile reads KEY=VALUE pairs from the given file path.
+// If the file does not exist, returns an empty map (not an error).
+func ParseFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]string), nil
 		}
+		return nil, fmt.Errorf("opening env file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return Parse(f)
+}
+
+// parser holds the state of a single Parse call.
+type parser struct {
+	src  string
+	pos  int
+	line int
+	env  map[string]string
+}
+
+func (p *parser) eof() bool { return p.pos >= len(p.src) }
+
+// skipBlanks skips spaces, tabs and carriage returns on the current line.
+func (p *parser) skipBlanks() {
+	for !p.eof() && isBlank(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// skipLine skips to the start of the next line.
+func (p *parser) skipLine() {
+	for !p.eof() && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+	p.endLine()
+}
+
+// endLine consumes the newline at the current position, if any.
+func (p *parser) endLine() {
+	if !p.eof() && p.src[p.pos] == '\n' {
+		p.pos++
+		p.line++
+	}
+}
+
+// parseLine parses one entry (which may span several lines for quoted values).
+func (p *parser) parseLine() error {
+	p.skipBlanks()
+	if p.eof() {
+		return nil
+	}
+	switch p.src[p.pos] {
+	case '\n':
+		p.endLine()
+		return nil
+	case '#':
+		p.skipLine()
+		return nil
+	}
+
+	lineNum := p.line
+	rest := p.src[p.pos:]
+	eol := strings.IndexByte(rest, '\n')
+	if eol < 0 {
+		eol = len(rest)
+	}
+	eq := strings.IndexByte(rest[:eol], '=')
+	if eq < 0 {
+		return fmt.Errorf("line %d: invalid format (expected KEY=VALUE): %q", lineNum, strings.TrimSpace(rest[:eol]))
+	}
+
+	key := strings.TrimSpace(rest[:eq])
+	if after, ok := strings.CutPrefix(key, "export"); ok && after != "" && isBlank(after[0]) {
+		key = strings.TrimSpace(after)
+	}
+	if key == "" {
+		return fmt.Errorf("line %d: empty key", lineNum)
+	}
+	if !ValidKey(key) {
+		return fmt.Errorf("line %d: invalid key %q", lineNum, key)
+	}
+
+	p.pos += eq + 1
+	p.skipBlanks()
+
+	var value string
+	var err error
+	switch {
+	case p.eof():
+		value = ""
+	case p.src[p.pos] == '\'':
+		value, err = p.parseSingleQuoted(lineNum)
+	case p.src[p.pos] == '"':
+		value, err = p.parseDoubleQuoted(lineNum)
+	default:
+		value, err = p.parseUnquoted(lineNum)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.env[key] = value
+	return nil
+}
+
+func (p *parser) parseSingleQuoted(lineNum int) (string, error) {
+	p.pos++ // opening quote
+	end := strings.IndexByte(p.src[p.pos:], '\'')
+	if end < 0 {
+		return "", fmt.Errorf("line %d: unterminated single-quoted value", lineNum)
+	}
+	value := p.src[p.pos : p.pos+end]
+	p.line += strings.Count(value, "\n")
+	p.pos += end + 1
+	return value, p.finishQuoted()
+}
 
-		key, value, ok := strings.Cut(line, "=")
-		if !ok {
-			return nil, fmt.Errorf("line %d: invalid format (expected KEY=VALUE): %q", lineNum, line)
+func (p *parser) parseDoubleQuoted(lineNum int) (string, error) {
+	p.pos++ // opening quote
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("line %d: unterminated double-quoted value", lineNum)
 		}
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), p.finishQuoted()
+		case '\\':
+			if p.pos+1 < len(p.src) {
+				if r, ok := unescape(p.src[p.pos+1]); ok {
+					b.WriteByte(r)
+					p.pos += 2
+					continue
+				}
+			}
+			b.WriteByte(c)
+			p.pos++
+		case '$':
+			value, n, err := p.expandAt(p.src, p.pos)
+			if err != nil {
+				return "", fmt.Errorf("line %d: %w", p.line, err)
+			}
+			b.WriteString(value)
+			p.pos += n
+		default:
+			if c == '\n' {
+				p.line++
+			}
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
 
-		key = strings.TrimSpace(key)
-		value = strings.TrimSpace(value)
+// finishQuoted checks that only whitespace or a comment follows a closing
+// quote, then moves to the next line.
+func (p *parser) finishQuoted() error {
+	p.skipBlanks()
+	if p.eof() {
+		return nil
+	}
+	switch p.src[p.pos] {
+	case '\n':
+		p.endLine()
+		return nil
+	case '#':
+		p.skipLine()
+		return nil
+	}
+	return fmt.Errorf("line %d: unexpected characters after closing quote", p.line)
+}
 
-		if key == "" {
-			return nil, fmt.Errorf("line %d: empty key", lineNum)
+func (p *parser) parseUnquoted(lineNum int) (string, error) {
+	rest := p.src[p.pos:]
+	eol := strings.IndexByte(rest, '\n')
+	if eol < 0 {
+		eol = len(rest)
+	}
+	raw := rest[:eol]
+
+	// Inline comment: a # preceded by whitespace (including the whitespace
+	// after '=' that was already skipped)
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && isBlank(p.src[p.pos+i-1]) {
+			raw = raw[:i]
+			break
 		}
+	}
+	p.pos += eol
+	p.endLine()
+	raw = strings.TrimSpace(raw)
 
-		// Strip optional double quotes
-		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
-			value = value[1 : len(value)-1]
+	var b strings.Builder
+	for i := 0; i < len(raw); {
+		if raw[i] != '$' {
+			b.WriteByte(raw[i])
+			i++
+			continue
+		}
+		value, n, err := p.expandAt(raw, i)
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", lineNum, err)
 		}
+		b.WriteString(value)
+		i += n
+	}
+	return b.String(), nil
+}
 
-		env[key] = value
+// expandAt expands the variable reference starting at s[i] (which must be
+// '$'). It returns the replacement and the number of bytes consumed.
+// A '$' that does not start a reference is kept literally.
+func (p *parser) expandAt(s string, i int) (string, int, error) {
+	if i+1 < len(s) && s[i+1] == '{' {
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			return "", 0, fmt.Errorf("unterminated variable reference %q", s[i:])
+		}
+		name := s[i+2 : i+2+end]
+		if !isVarName(name) {
+			return "", 0, fmt.Errorf("invalid variable name %q", name)
+		}
+		return p.lookup(name), end + 3, nil
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("reading env file: %w", err)
+	n := 1
+	for i+n < len(s) && isVarChar(s[i+n], n == 1) {
+		n++
+	}
+	if n == 1 {
+		return "$", 1, nil
 	}
+	return p.lookup(s[i+1 : i+n]), n, nil
+}
 
-	return env, nil
+// lookup resolves a variable against earlier keys, then the host environment.
+func (p *parser) lookup(name string) string {
+	if v, ok := p.env[name]; ok {
+		return v
+	}
+	v, _ := os.LookupEnv(name)
+	return v
 }
 
-// ParseFile reads KEY=VALUE pairs from the given file path.
-// If the file does not exist, returns an empty map (not an error).
-func ParseFile(path string) (map[string]string, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return make(map[string]string), nil
+// ValidKey reports whether key can be used as an env file key: a letter or
+// underscore followed by letters, digits, underscores, dots or hyphens.
+func ValidKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c == '_' || isLetter(c):
+		case i > 0 && (isDigit(c) || c == '.' || c == '-'):
+		default:
+			return false
 		}
-		return nil, fmt.Errorf("opening env file: %w", err)
 	}
-	defer func() { _ = f.Close() }()
+	return true
+}
 
-	return Parse(f)
+func isVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isVarChar(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
 }
+
+func isVarChar(c byte, first bool) bool {
+	return c == '_' || isLetter(c) || (!first && isDigit(c))
+}
+
+func unescape(c byte) (byte, bool) {
+	switch c {
+	case 'n':
+		return '\n', true
+	case 'r':
+		return '\r', true
+	case 't':
+		return '\t', true
+	case '"', '\\', '$':
+		return c, true
+	}
+	return 0, false
+}
+
+func isBlank(c byte) bool  { return c == ' ' || c == '\t' || c == '\r' }
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
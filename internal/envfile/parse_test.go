@@ -146,3 +146,164 @@ func TestParseFile_ValidFile(t *testing.T) {
 		t.Errorf("BAZ = %q, want %q", env["BAZ"], "qux")
 	}
 }
+
+func TestParse_ExportPrefix(t *testing.T) {
+	input := "export FOO=bar\nexport\tBAZ=\"qux\"\nexport=literal"
+	env, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env["FOO"] != "bar" {
+		t.Errorf("FOO = %q, want %q", env["FOO"], "bar")
+	}
+	if env["BAZ"] != "qux" {
+		t.Errorf("BAZ = %q, want %q", env["BAZ"], "qux")
+	}
+	if env["export"] != "literal" {
+		t.Errorf("export = %q, want %q", env["export"], "literal")
+	}
+}
+
+func TestParse_SingleQuotesAreLiteral(t *testing.T) {
+	input := `FOO='bar $HOME \n "baz" # not a comment'`
+	env, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `bar $HOME \n "baz" # not a comment`
+	if env["FOO"] != want {
+		t.Errorf("FOO = %q, want %q", env["FOO"], want)
+	}
+}
+
+func TestParse_DoubleQuoteEscapes(t *testing.T) {
+	input := `FOO="a\nb\tc\r\"d\" \\ \$HOME \x"`
+	env, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "a\nb\tc\r\"d\" \\ $HOME \\x"
+	if env["FOO"] != want {
+		t.Errorf("FOO = %q, want %q", env["FOO"], want)
+	}
+}
+
+func TestParse_MultiLineQuotedValues(t *testing.T) {
+	input := "CERT=\"-----BEGIN-----\nabc\n-----END-----\"\nKEY='line1\nline2'\nAFTER=ok\nBROKEN"
+	_, err := Parse(strings.NewReader(input))
+	if err == nil {
+		t.Fatal("expected error for line without =")
+	}
+	if !strings.Contains(err.Error(), "line 7") {
+		t.Errorf("error = %q, want line 7 (line numbers should count lines inside quoted values)", err.Error())
+	}
+
+	env, err := Parse(strings.NewReader(strings.TrimSuffix(input, "\nBROKEN")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env["CERT"] != "-----BEGIN-----\nabc\n-----END-----" {
+		t.Errorf("CERT = %q", env["CERT"])
+	}
+	if env["KEY"] != "line1\nline2" {
+		t.Errorf("KEY = %q", env["KEY"])
+	}
+	if env["AFTER"] != "ok" {
+		t.Errorf("AFTER = %q, want %q", env["AFTER"], "ok")
+	}
+}
+
+func TestParse_InlineComments(t *testing.T) {
+	input := "A=bar # comment\nB=\"quoted # kept\" # comment\nC='single' #comment\nD=no#comment\nE= # only comment"
+	env, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"A": "bar",
+		"B": "quoted # kept",
+		"C": "single",
+		"D": "no#comment",
+		"E": "",
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("%s = %q, want %q", k, env[k], v)
+		}
+	}
+}
+
+func TestParse_Interpolation(t *testing.T) {
+	t.Setenv("AW_ENVFILE_HOST_VAR", "from-host")
+
+	input := strings.Join([]string{
+		"BASE=http://localhost",
+		"URL=${BASE}:8080/api",
+		`QUOTED="$BASE/quoted"`,
+		"SINGLE='${BASE}'",
+		"HOST=${AW_ENVFILE_HOST_VAR}",
+		"MISSING=[${AW_ENVFILE_UNSET_VAR}]",
+		"PRICE=5$",
+		"AW_ENVFILE_HOST_VAR=overridden",
+		"AFTER=$AW_ENVFILE_HOST_VAR",
+	}, "\n")
+	env, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"URL":     "http://localhost:8080/api",
+		"QUOTED":  "http://localhost/quoted",
+		"SINGLE":  "${BASE}",
+		"HOST":    "from-host",
+		"MISSING": "[]",
+		"PRICE":   "5$",
+		"AFTER":   "overridden",
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("%s = %q, want %q", k, env[k], v)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"unterminated double quote", "FOO=\"bar\nBAZ=qux", "line 1: unterminated double-quoted value"},
+		{"unterminated single quote", "OK=1\nFOO='bar", "line 2: unterminated single-quoted value"},
+		{"text after closing quote", `FOO="bar"baz`, "unexpected characters after closing quote"},
+		{"invalid key", "FOO BAR=baz", "invalid key"},
+		{"unterminated reference", "FOO=${BAR", "unterminated variable reference"},
+		{"invalid reference", "FOO=${1BAR}", "invalid variable name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input))
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want containing %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_CRLF(t *testing.T) {
+	input := "FOO=bar\r\nBAZ=\"qux\"\r\n"
+	env, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env["FOO"] != "bar" {
+		t.Errorf("FOO = %q, want %q", env["FOO"], "bar")
+	}
+	if env["BAZ"] != "qux" {
+		t.Errorf("BAZ = %q, want %q", env["BAZ"], "qux")
+	}
+}
@@ -1,12 +1,36 @@
 package envfile
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
 )
 
+// Write writes KEY=VALUE pairs to w in a format that Parse reads back
+// unchanged. Keys are sorted for deterministic output. Values made only of
+// safe characters are written as-is; anything else is double-quoted with
+// backslash escapes.
+func Write(w io.Writer, env map[string]string) error {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		if !ValidKey(k) {
+			return fmt.Errorf("invalid key %q", k)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", k, quote(env[k]))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
 // WriteFile writes KEY=VALUE pairs to the given file path.
 // Keys are sorted for deterministic output.
 // If env is empty or nil, no file is created.
@@ -15,16 +39,49 @@ func WriteFile(path string, env map[string]string) error {
 		return nil
 	}
 
-	keys := make([]string, 0, len(env))
-	for k := range env {
-		keys = append(keys, k)
+	var buf bytes.Buffer
+	if err := Write(&buf, env); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// quote returns value in a form Parse decodes back to value.
+func quote(value string) string {
+	if !needsQuoting(value) {
+		return value
 	}
-	sort.Strings(keys)
 
 	var b strings.Builder
-	for _, k := range keys {
-		fmt.Fprintf(&b, "%s=%s\n", k, env[k])
+	b.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\', '"', '$':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteByte(c)
+		}
 	}
+	b.WriteByte('"')
+	return b.String()
+}
 
-	return os.WriteFile(path, []byte(b.String()), 0644)
+// needsQuoting reports whether value contains characters that are not safe
+// in an unquoted value.
+func needsQuoting(value string) bool {
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if isLetter(c) || isDigit(c) {
+			continue
+		}
+		if !strings.ContainsRune("_-./:@+,=%", rune(c)) {
+			return true
+		}
+	}
+	return false
 }
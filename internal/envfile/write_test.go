@@ -3,6 +3,7 @@ package envfile
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -114,3 +115,84 @@ func TestWriteFile_RoundTrip(t *testing.T) {
 		t.Errorf("got %d entries, want %d", len(got), len(env))
 	}
 }
+
+func TestWrite_QuotesValuesThatNeedIt(t *testing.T) {
+	env := map[string]string{
+		"PLAIN":   "http://host:1234/path",
+		"SPACE":   "hello world",
+		"HASH":    "a#b",
+		"NEWLINE": "line1\nline2",
+		"DOLLAR":  "pa$$word",
+		"QUOTES":  `say "hi"`,
+		"EMPTY":   "",
+	}
+
+	var b strings.Builder
+	if err := Write(&b, env); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		`DOLLAR="pa\$\$word"`,
+		`EMPTY=`,
+		`HASH="a#b"`,
+		`NEWLINE="line1\nline2"`,
+		`PLAIN=http://host:1234/path`,
+		`QUOTES="say \"hi\""`,
+		`SPACE="hello world"`,
+	}, "\n") + "\n"
+	if b.String() != expected {
+		t.Errorf("output = %q, want %q", b.String(), expected)
+	}
+}
+
+func TestWrite_InvalidKey(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, map[string]string{"BAD KEY": "x"}); err == nil {
+		t.Fatal("expected error for invalid key")
+	}
+}
+
+func FuzzWriteParseRoundTrip(f *testing.F) {
+	seeds := []struct{ key, value string }{
+		{"FOO", "bar"},
+		{"EMPTY", ""},
+		{"URL", "http://host.docker.internal:52296?a=b&c=d"},
+		{"MULTI", "line1\nline2\r\n"},
+		{"QUOTES", `'single' "double" \backslash\`},
+		{"VARS", "$HOME ${HOME} $ ${"},
+		{"COMMENT", "  value # not a comment  "},
+		{"export", "export FOO=bar"},
+		{"UNICODE", "日本語\t\x00\xff"},
+	}
+	for _, s := range seeds {
+		f.Add(s.key, s.value)
+	}
+
+	f.Fuzz(func(t *testing.T, key, value string) {
+		if !ValidKey(key) {
+			key = "KEY"
+		}
+		env := map[string]string{key: value, "OTHER": value + "x"}
+		if key == "OTHER" {
+			delete(env, "OTHER")
+		}
+
+		var b strings.Builder
+		if err := Write(&b, env); err != nil {
+			t.Fatalf("Write error: %v", err)
+		}
+		got, err := Parse(strings.NewReader(b.String()))
+		if err != nil {
+			t.Fatalf("Parse error: %v\ninput: %q", err, b.String())
+		}
+		if len(got) != len(env) {
+			t.Fatalf("got %d entries, want %d\ninput: %q", len(got), len(env), b.String())
+		}
+		for k, want := range env {
+			if got[k] != want {
+				t.Errorf("%s = %q, want %q\ninput: %q", k, got[k], want, b.String())
+			}
+		}
+	})
+}
@@ -6,7 +6,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"syscall"
 
 	"github.com/hiragram/agent-workspace/internal/docker"
 	"github.com/hiragram/agent-workspace/internal/pipeline"
@@ -35,34 +34,34 @@ func (l *ClaudeLauncher) launchHostClaude(ec *pipeline.ExecutionContext) error {
 
 	fmt.Fprintf(os.Stderr, "Launching Claude in %s\n", ec.WorkDir)
 
-	args := []string{"claude"}
-	// Use syscall.Exec to replace the current process
-	env := os.Environ()
-	return syscall.Exec(claudePath, args, env)
+	return runHost(ec, claudePath, []string{"claude"})
 }
 
 func (l *ClaudeLauncher) launchDockerClaude(ctx context.Context, ec *pipeline.ExecutionContext) error {
 	client := docker.NewShellClient()
+	return client.Run(ctx, DockerRunConfig(ec, ec.EnvVars, []string{"claude", "--dangerously-skip-permissions"}))
+}
 
-	command := []string{"claude", "--dangerously-skip-permissions"}
-
-	envVars := make(map[string]string, len(ec.EnvVars)+2)
-	for k, v := range ec.EnvVars {
+// DockerRunConfig returns the configuration for running command in the
+// workspace container built by the DockerStage, with env as its custom env
+// vars. It also sets the variables entrypoint.sh needs to run command as the
+// host user.
+func DockerRunConfig(ec *pipeline.ExecutionContext, env map[string]string, command []string) docker.RunConfig {
+	envVars := make(map[string]string, len(env)+2)
+	for k, v := range env {
 		envVars[k] = v
 	}
 	// Hardcoded vars always win — users cannot override these
 	envVars["HOST_CLAUDE_HOME"] = claudeHomePath(ec.HomeDir)
 	envVars["HOST_WORKSPACE"] = ec.WorkDir
 
-	runConfig := docker.RunConfig{
+	return docker.RunConfig{
 		ImageName: ec.DockerImage,
 		Mounts:    ec.DockerMounts,
 		EnvVars:   envVars,
 		WorkDir:   ec.WorkDir,
 		Command:   command,
 	}
-
-	return client.Run(ctx, runConfig)
 }
 
 func claudeHomePath(homeDir string) string {
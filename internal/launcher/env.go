@@ -0,0 +1,40 @@
+package launcher
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hiragram/agent-workspace/internal/pipeline"
+)
+
+// hostEnv returns the environment for a process launched on the host:
+// the current environment, overlaid with the custom env vars from EnvStage
+// and the AW_* workspace variables.
+func hostEnv(ec *pipeline.ExecutionContext) []string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok && k != "" {
+			env[k] = v
+		}
+	}
+	for k, v := range ec.EnvVars {
+		env[k] = v
+	}
+	// Workspace vars always win — users cannot override these
+	for k, v := range ec.WorkspaceEnv() {
+		env[k] = v
+	}
+
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]string, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, k+"="+env[k])
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package launcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hiragram/agent-workspace/internal/pipeline"
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+func envMap(env []string) map[string]string {
+	m := make(map[string]string)
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		m[k] = v
+	}
+	return m
+}
+
+func TestHostEnv(t *testing.T) {
+	t.Setenv("AW_TEST_INHERITED", "host")
+	t.Setenv("AW_TEST_OVERRIDDEN", "host")
+	t.Setenv("AW_PROFILE_NAME", "stale")
+
+	ec := &pipeline.ExecutionContext{
+		Profile:     profile.Profile{Environment: profile.EnvironmentHost},
+		ProfileName: "worktree-shell",
+		EnvVars: map[string]string{
+			"AW_TEST_OVERRIDDEN": "custom",
+			"AW_TEST_CUSTOM":     "custom",
+			"AW_PROFILE_NAME":    "user-value",
+		},
+		WorktreePath: "/repo/worktrees/foo",
+	}
+
+	env := envMap(hostEnv(ec))
+
+	if env["AW_TEST_INHERITED"] != "host" {
+		t.Errorf("AW_TEST_INHERITED = %q, want %q", env["AW_TEST_INHERITED"], "host")
+	}
+	if env["AW_TEST_OVERRIDDEN"] != "custom" {
+		t.Errorf("AW_TEST_OVERRIDDEN = %q, want %q (custom env should override host)", env["AW_TEST_OVERRIDDEN"], "custom")
+	}
+	if env["AW_TEST_CUSTOM"] != "custom" {
+		t.Errorf("AW_TEST_CUSTOM = %q, want %q", env["AW_TEST_CUSTOM"], "custom")
+	}
+	if env["AW_PROFILE_NAME"] != "worktree-shell" {
+		t.Errorf("AW_PROFILE_NAME = %q, want %q (workspace vars should always win)", env["AW_PROFILE_NAME"], "worktree-shell")
+	}
+	if env["AW_WORKTREE_PATH"] != "/repo/worktrees/foo" {
+		t.Errorf("AW_WORKTREE_PATH = %q, want %q", env["AW_WORKTREE_PATH"], "/repo/worktrees/foo")
+	}
+}
+
+func TestHostEnv_NoDuplicateKeys(t *testing.T) {
+	t.Setenv("AW_TEST_DUP", "host")
+
+	ec := &pipeline.ExecutionContext{
+		EnvVars: map[string]string{"AW_TEST_DUP": "custom"},
+	}
+
+	count := 0
+	for _, kv := range hostEnv(ec) {
+		if strings.HasPrefix(kv, "AW_TEST_DUP=") {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("AW_TEST_DUP appears %d times, want 1", count)
+	}
+}
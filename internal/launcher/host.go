@@ -0,0 +1,56 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/hiragram/agent-workspace/internal/pipeline"
+	"github.com/hiragram/agent-workspace/internal/proc"
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+// runHost runs a program on the host in ec.WorkDir.
+//
+// If something has to run after the program exits (the on-end or post-launch
+// hooks), the program is started as a child process and supervised until it
+// exits.
+// Otherwise aw replaces itself with the program via exec.
+func runHost(ec *pipeline.ExecutionContext, path string, args []string) error {
+	env := hostEnv(ec)
+
+	if needsSupervision(ec) {
+		return superviseHost(ec.WorkDir, path, args, env)
+	}
+
+	if ec.WorkDir != "" {
+		if err := os.Chdir(ec.WorkDir); err != nil {
+			return fmt.Errorf("changing directory to %s: %w", ec.WorkDir, err)
+		}
+	}
+	return syscall.Exec(path, args, env)
+}
+
+// needsSupervision reports whether aw must keep running after launching a
+// host process, to run the on-end or post-launch hooks.
+func needsSupervision(ec *pipeline.ExecutionContext) bool {
+	if len(ec.Profile.Hooks[profile.HookPostLaunch]) > 0 {
+		return true
+	}
+	return ec.Profile.Worktree != nil && ec.Profile.Worktree.OnEnd != ""
+}
+
+// superviseHost runs the program as a child process attached to the
+// terminal and waits for it to exit.
+func superviseHost(dir, path string, args, env []string) error {
+	cmd := exec.Command(path)
+	cmd.Args = args
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return proc.RunForeground(cmd)
+}
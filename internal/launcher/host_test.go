@@ -0,0 +1,92 @@
+package launcher
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hiragram/agent-workspace/internal/pipeline"
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+func TestNeedsSupervision(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile profile.Profile
+		want    bool
+	}{
+		{"no worktree", profile.Profile{}, false},
+		{"worktree without on-end", profile.Profile{Worktree: &profile.WorktreeConfig{}}, false},
+		{"worktree with on-end", profile.Profile{Worktree: &profile.WorktreeConfig{OnEnd: "echo done"}}, true},
+		{"post-launch hook", profile.Profile{Hooks: profile.Hooks{profile.HookPostLaunch: {{Run: "echo done"}}}}, true},
+		{"pre-launch hook only", profile.Profile{Hooks: profile.Hooks{profile.HookPreLaunch: {{Run: "echo start"}}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := &pipeline.ExecutionContext{Profile: tt.profile}
+			if got := needsSupervision(ec); got != tt.want {
+				t.Errorf("needsSupervision() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunHost_SupervisedRunsInWorkDir(t *testing.T) {
+	shPath, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	workDir := t.TempDir()
+	ec := &pipeline.ExecutionContext{
+		Profile: profile.Profile{
+			Worktree:    &profile.WorktreeConfig{OnEnd: "echo done"},
+			Environment: profile.EnvironmentHost,
+		},
+		ProfileName: "worktree-shell",
+		WorkDir:     workDir,
+		EnvVars:     map[string]string{"AW_TEST_CUSTOM": "custom"},
+	}
+
+	script := `pwd > out.txt && echo "$AW_TEST_CUSTOM $AW_PROFILE_NAME" >> out.txt`
+	if err := runHost(ec, shPath, []string{"sh", "-c", script}); err != nil {
+		t.Fatalf("runHost() error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(workDir, "out.txt"))
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected output: %q", string(data))
+	}
+	if lines[0] != workDir {
+		t.Errorf("working directory = %q, want %q", lines[0], workDir)
+	}
+	if lines[1] != "custom worktree-shell" {
+		t.Errorf("env = %q, want %q", lines[1], "custom worktree-shell")
+	}
+}
+
+func TestRunHost_SupervisedReturnsExitError(t *testing.T) {
+	shPath, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	ec := &pipeline.ExecutionContext{
+		Profile: profile.Profile{
+			Worktree:    &profile.WorktreeConfig{OnEnd: "echo done"},
+			Environment: profile.EnvironmentHost,
+		},
+		WorkDir: t.TempDir(),
+	}
+
+	if err := runHost(ec, shPath, []string{"sh", "-c", "exit 3"}); err == nil {
+		t.Fatal("expected error from failing process, got nil")
+	}
+}
@@ -0,0 +1,96 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hiragram/agent-workspace/internal/docker"
+	"github.com/hiragram/agent-workspace/internal/pipeline"
+	"github.com/hiragram/agent-workspace/internal/profile"
+	"github.com/hiragram/agent-workspace/internal/worktree"
+)
+
+// Helper scripts run in the panes of the zellij and tmux layouts.
+const (
+	plansWatcherScript  = "plans-watcher.sh"
+	gitDiffPickerScript = "git-diff-picker.sh"
+	prStatusScript      = "pr-status.sh"
+)
+
+// workspaceSessionName returns the name of the zellij or tmux session for
+// ec: the worktree branch (with "/" replaced like in worktree directory
+// names), or without a worktree "<repository directory>-<profile>", so that
+// the same profile run in two repositories does not share a session.
+func workspaceSessionName(ec *pipeline.ExecutionContext) string {
+	if name := worktree.DirName(ec.WorktreeBranch); name != "" {
+		return name
+	}
+	return filepath.Base(repoDir(ec)) + "-" + ec.ProfileName
+}
+
+// repoDir returns the root of the repository ec runs in, or its working
+// directory outside a repository.
+func repoDir(ec *pipeline.ExecutionContext) string {
+	if ec.RepoRoot != "" {
+		return ec.RepoRoot
+	}
+	out, err := exec.Command("git", "-C", ec.WorkDir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return ec.WorkDir
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// writeHelperScripts writes the pane helper scripts into dir, creating it.
+func writeHelperScripts(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating scripts dir: %w", err)
+	}
+	scripts := map[string][]byte{
+		plansWatcherScript:  plansWatcherSh,
+		gitDiffPickerScript: gitDiffPickerSh,
+		prStatusScript:      prStatusSh,
+	}
+	for name, content := range scripts {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0755); err != nil {
+			return fmt.Errorf("writing %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// claudePaneCommand returns the shell command that runs Claude Code in a
+// pane of a multi-pane layout. In docker mode, the pane's environment must
+// hold the values of the container's env vars (see docker.RunEnv).
+func claudePaneCommand(ec *pipeline.ExecutionContext) string {
+	switch ec.Profile.Environment {
+	case profile.EnvironmentDocker:
+		// Build docker run command directly using the image already built
+		// by the DockerStage, so we don't re-run the pipeline with a
+		// different profile that would lose custom Dockerfile settings.
+		// The env vars are passed by name, so their values stay out of
+		// the command line and the layout files.
+		args := docker.BuildRunArgs(DockerRunConfig(ec, ec.EnvVars, []string{"claude", "--dangerously-skip-permissions"}))
+		return "docker " + shellJoin(args)
+	default:
+		// Host mode: just run claude directly
+		return "claude"
+	}
+}
+
+// shellJoin quotes arguments for safe shell embedding.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if strings.ContainsAny(a, " \t\n\"'\\$`!#&|;(){}") {
+			quoted[i] = "'" + strings.ReplaceAll(a, "'", "'\"'\"'") + "'"
+		} else {
+			quoted[i] = a
+		}
+	}
+	return strings.Join(quoted, " ")
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"syscall"
 
 	"github.com/hiragram/agent-workspace/internal/docker"
 	"github.com/hiragram/agent-workspace/internal/pipeline"
@@ -39,29 +38,10 @@ func (l *ShellLauncher) launchHostShell(ec *pipeline.ExecutionContext) error {
 
 	fmt.Fprintf(os.Stderr, "Opening shell in %s\n", ec.WorkDir)
 
-	// Use syscall.Exec to replace the current process
-	env := os.Environ()
-	return syscall.Exec(shellPath, []string{shell}, env)
+	return runHost(ec, shellPath, []string{shell})
 }
 
 func (l *ShellLauncher) launchDockerShell(ctx context.Context, ec *pipeline.ExecutionContext) error {
 	client := docker.NewShellClient()
-
-	envVars := make(map[string]string, len(ec.EnvVars)+2)
-	for k, v := range ec.EnvVars {
-		envVars[k] = v
-	}
-	// Hardcoded vars always win — users cannot override these
-	envVars["HOST_CLAUDE_HOME"] = claudeHomePath(ec.HomeDir)
-	envVars["HOST_WORKSPACE"] = ec.WorkDir
-
-	runConfig := docker.RunConfig{
-		ImageName: ec.DockerImage,
-		Mounts:    ec.DockerMounts,
-		EnvVars:   envVars,
-		WorkDir:   ec.WorkDir,
-		Command:   []string{"/bin/bash"},
-	}
-
-	return client.Run(ctx, runConfig)
+	return client.Run(ctx, DockerRunConfig(ec, ec.EnvVars, []string{"/bin/bash"}))
 }
@@ -0,0 +1,283 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hiragram/agent-workspace/internal/pipeline"
+	"github.com/hiragram/agent-workspace/internal/proc"
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+// TmuxLauncher launches a tmux session with the same panes as the zellij
+// layout.
+type TmuxLauncher struct{}
+
+func (l *TmuxLauncher) Launch(_ context.Context, ec *pipeline.ExecutionContext) error {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return fmt.Errorf("tmux is not installed (brew install tmux)")
+	}
+
+	sessionName := tmuxSessionName(ec)
+
+	// Reattach to a session left running by an earlier launch (e.g. when
+	// resuming a worktree)
+	if tmuxHasSession(sessionName) {
+		fmt.Fprintf(os.Stderr, "Attaching to tmux session: %s\n", sessionName)
+		return attachTmux(ec, sessionName)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "aw-tmux-*")
+	if err != nil {
+		return fmt.Errorf("preparing tmux files: creating temp dir: %w", err)
+	}
+	scriptsDir := filepath.Join(tmpDir, "scripts")
+	if err := writeHelperScripts(scriptsDir); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return fmt.Errorf("preparing tmux files: %w", err)
+	}
+
+	// The session is created detached and then attached to, so that its
+	// environment can be read from stdin: the values (e.g. tokens from
+	// env-passthrough) stay out of the command line
+	fmt.Fprintf(os.Stderr, "Launching tmux session: %s\n", sessionName)
+	cols, rows := terminalSize()
+	args := tmuxLayoutArgs(sessionName, ec.WorkDir, scriptsDir, claudePaneCommand(ec), cols, rows)
+	args = append(args, ";", "set-option", "-t", tmuxTarget(sessionName), tmuxTmpDirOption, tmpDir)
+	create := exec.Command("tmux", args...)
+	create.Dir = ec.WorkDir
+	create.Stdin = strings.NewReader(tmuxEnvScript(sessionName, tmuxSessionEnv(ec)))
+	create.Stderr = os.Stderr
+	if err := create.Run(); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		// Not wrapped: the launch stage takes an *exec.ExitError for the
+		// exit of the session itself
+		return fmt.Errorf("creating tmux session: %v", err)
+	}
+	err = attachTmux(ec, sessionName)
+
+	// The panes of a detached session still run the scripts; tmux removes
+	// them when it ends (see watchTmuxSession)
+	if !tmuxHasSession(sessionName) {
+		_ = os.RemoveAll(tmpDir)
+	}
+	return err
+}
+
+// tmuxTmpDirOption is the session option that holds the directory of the
+// session's helper scripts.
+const tmuxTmpDirOption = "@aw-tmpdir"
+
+// tmuxTarget returns the target of the current window of the session: the
+// exact name, not a prefix, and usable for commands that take a pane.
+func tmuxTarget(sessionName string) string {
+	return "=" + sessionName + ":"
+}
+
+func tmuxHasSession(name string) bool {
+	return exec.Command("tmux", "has-session", "-t", "="+name).Run() == nil
+}
+
+// tmuxSessionName returns the session name for ec (see
+// workspaceSessionName). tmux does not allow "." or ":" in session names.
+func tmuxSessionName(ec *pipeline.ExecutionContext) string {
+	return strings.NewReplacer(".", "_", ":", "_").Replace(workspaceSessionName(ec))
+}
+
+// tmuxLayoutArgs returns the tmux arguments that create the detached
+// session, set its environment from the commands on stdin (see
+// tmuxEnvScript) and split it into the layout of the zellij launcher:
+//
+//	+-------+-------------+---------+
+//	| Plans | Claude Code | Changed |
+//	|       |             | Files   |
+//	+-------+-------------+----+----+
+//	| Terminal                 | PR |
+//	+--------------------------+----+
+//
+// The session is created with the size of the terminal it is attached to
+// (tmux's default if cols or rows is 0), so that the panes are sized for
+// it. Claude Code has the focus.
+//
+// Every command names its target: run from a tmux pane, the current pane is
+// the one aw runs in rather than one of the new session.
+func tmuxLayoutArgs(sessionName, workDir, scriptsDir, claudeCmd string, cols, rows int) []string {
+	script := func(name string) string { return filepath.Join(scriptsDir, name) }
+	pane := func(name string) string { return tmuxTarget(sessionName) + "." + name }
+
+	args := []string{"new-session", "-d", "-s", sessionName, "-c", workDir}
+	if cols > 0 && rows > 0 {
+		args = append(args, "-x", strconv.Itoa(cols), "-y", strconv.Itoa(rows))
+	}
+	// The environment can only be set once the session exists, so the
+	// first pane is restarted with Claude Code after that
+	args = append(args, ";", "source-file", "-")
+	args = append(args, ";", "respawn-pane", "-k", "-t", pane(""), "-c", workDir, "bash", "-c", claudeCmd)
+
+	split := func(target, size string, extra ...string) {
+		args = append(args, ";", "split-window", "-d", "-t", pane(target), "-l", size, "-c", workDir)
+		args = append(args, extra...)
+	}
+	split("{top}", "30%", "-v")                                          // Terminal
+	split("{bottom}", "30%", "-h", "bash", "-c", script(prStatusScript)) // PR Status
+	split("{top}", "40", "-h", "bash", "-c", script(gitDiffPickerScript))
+	split("{top-left}", "30%", "-h", "-b", "bash", "-c", script(plansWatcherScript))
+
+	title := func(target, name string) {
+		args = append(args, ";", "select-pane", "-t", pane(target), "-T", name)
+	}
+	title("{top-left}", "Plans")
+	title("{top-right}", "Changed Files")
+	title("{bottom-left}", "Terminal")
+	title("{bottom-right}", "PR Status")
+	args = append(args, ";", "select-pane", "-t", pane(""), "-T", "Claude Code")
+	return append(args, ";", "set-option", "-w", "-t", tmuxTarget(sessionName), "pane-border-status", "top")
+}
+
+// tmuxSessionEnv returns the variables to set on a new session: in host
+// mode the custom env vars and the AW_* workspace variables, in docker mode
+// the values of the variables that claudePaneCommand passes into the
+// container.
+func tmuxSessionEnv(ec *pipeline.ExecutionContext) []string {
+	env := make(map[string]string)
+	if ec.Profile.Environment == profile.EnvironmentDocker {
+		env = DockerRunConfig(ec, ec.EnvVars, nil).EnvVars
+	} else {
+		for k, v := range ec.EnvVars {
+			env[k] = v
+		}
+		for k, v := range ec.WorkspaceEnv() {
+			env[k] = v
+		}
+	}
+	result := make([]string, 0, len(env))
+	for k, v := range env {
+		result = append(result, k+"="+v)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// tmuxEnvScript returns the tmux commands that set env, a list of
+// KEY=VALUE, in the session.
+func tmuxEnvScript(sessionName string, env []string) string {
+	var b strings.Builder
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		fmt.Fprintf(&b, "set-environment -t %s %s %s\n", tmuxQuote(tmuxTarget(sessionName)), tmuxQuote(k), tmuxQuote(v))
+	}
+	return b.String()
+}
+
+// tmuxQuote quotes s as a single argument of a tmux command.
+func tmuxQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`).Replace(s) + `"`
+}
+
+// terminalSize returns the size of the terminal aw runs in, or 0, 0 if
+// there is none.
+func terminalSize() (cols, rows int) {
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, 0
+	}
+	if _, err := fmt.Sscan(string(out), &rows, &cols); err != nil {
+		return 0, 0
+	}
+	return cols, rows
+}
+
+// attachTmux attaches the terminal to the session, or switches the current
+// client to it inside tmux. It returns when the user detaches from the
+// session or it ends. switch-client returns at once, so inside tmux it
+// waits for the session to end: the on-end and post-launch hooks must not
+// run while it is in use.
+func attachTmux(ec *pipeline.ExecutionContext, sessionName string) error {
+	channel, err := watchTmuxSession(sessionName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if err := runTmux(ec, attachArgs(sessionName)); err != nil {
+		return err
+	}
+	if !insideTmux() || channel == "" {
+		return nil
+	}
+	if err := exec.Command("tmux", "wait-for", channel).Run(); err != nil {
+		return fmt.Errorf("waiting for tmux session %s to end: %v", sessionName, err)
+	}
+	return nil
+}
+
+// watchTmuxSession sets a global session-closed hook that, when the session
+// ends, removes its helper scripts and signals the returned wait-for
+// channel. Hooks set on the session itself do not run once it is gone, so
+// the hook is global: it only acts on the session and then removes itself.
+func watchTmuxSession(sessionName string) (string, error) {
+	out, err := exec.Command("tmux", "display-message", "-p", "-t", tmuxTarget(sessionName),
+		"#{session_id} #{"+tmuxTmpDirOption+"}").Output()
+	if err != nil {
+		return "", fmt.Errorf("watching tmux session %s: %v", sessionName, err)
+	}
+	id, tmpDir, _ := strings.Cut(strings.TrimSuffix(string(out), "\n"), " ")
+	n, err := strconv.Atoi(strings.TrimPrefix(id, "$"))
+	if err != nil {
+		return "", fmt.Errorf("watching tmux session %s: unexpected session id %q", sessionName, id)
+	}
+	if tmpDir == "" {
+		return "", fmt.Errorf("watching tmux session %s: it was not started by aw", sessionName)
+	}
+	if err := exec.Command("tmux", tmuxCloseHookArgs(n, tmpDir)...).Run(); err != nil {
+		return "", fmt.Errorf("watching tmux session %s: %v", sessionName, err)
+	}
+	return tmuxClosedChannel(n), nil
+}
+
+// tmuxClosedChannel returns the wait-for channel signalled when the session
+// with id $n ends.
+func tmuxClosedChannel(n int) string {
+	return "aw-session-" + strconv.Itoa(n)
+}
+
+// tmuxCloseHookArgs returns the tmux arguments that set the session-closed
+// hook of the session with id $n (see watchTmuxSession), which removes
+// tmpDir.
+func tmuxCloseHookArgs(n int, tmpDir string) []string {
+	hook := fmt.Sprintf("session-closed[%d]", n)
+	cmds := []string{
+		"wait-for -S " + tmuxClosedChannel(n),
+		"run-shell " + tmuxQuote(shellJoin([]string{"rm", "-rf", tmpDir})),
+		"set-hook -gu " + hook,
+	}
+	cond := fmt.Sprintf("#{==:#{hook_session},$%d}", n)
+	return []string{"set-hook", "-g", hook, fmt.Sprintf("if-shell -F '%s' { %s }", cond, strings.Join(cmds, " ; "))}
+}
+
+func attachArgs(sessionName string) []string {
+	if insideTmux() {
+		return []string{"switch-client", "-t", "=" + sessionName}
+	}
+	return []string{"attach-session", "-t", "=" + sessionName}
+}
+
+// insideTmux reports whether aw runs in a tmux pane.
+func insideTmux() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+func runTmux(ec *pipeline.ExecutionContext, args []string) error {
+	cmd := exec.Command("tmux", args...)
+	cmd.Dir = ec.WorkDir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return proc.RunForeground(cmd)
+}
@@ -0,0 +1,114 @@
+package launcher
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hiragram/agent-workspace/internal/pipeline"
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+func TestTmuxSessionName(t *testing.T) {
+	tests := []struct {
+		branch, profile, want string
+	}{
+		{"feat/login", "dev", "feat-login"},
+		{"release/2.0", "dev", "release-2_0"},
+		{"", "dev", "my_app-dev"},
+	}
+	for _, tt := range tests {
+		ec := &pipeline.ExecutionContext{WorktreeBranch: tt.branch, ProfileName: tt.profile, WorkDir: "/src/my.app"}
+		if got := tmuxSessionName(ec); got != tt.want {
+			t.Errorf("tmuxSessionName(%q, %q) = %q, want %q", tt.branch, tt.profile, got, tt.want)
+		}
+	}
+}
+
+func TestTmuxLayoutArgs(t *testing.T) {
+	args := tmuxLayoutArgs("feat-login", "/wt", "/tmp/scripts", "claude", 200, 50)
+
+	want := []string{"new-session", "-d", "-s", "feat-login", "-c", "/wt", "-x", "200", "-y", "50", ";", "source-file", "-"}
+	if !slices.Equal(args[:len(want)], want) {
+		t.Errorf("args start with %q, want %q", args[:len(want)], want)
+	}
+	if !slices.Contains(args, "claude") {
+		t.Errorf("args do not run claude: %q", args)
+	}
+	// Inside tmux, a command without a target acts on aw's own pane
+	for i, a := range args {
+		if i == 0 || args[i-1] != ";" || a == "source-file" {
+			continue
+		}
+		cmd := args[i:]
+		if end := slices.Index(cmd, ";"); end >= 0 {
+			cmd = cmd[:end]
+		}
+		if !slices.Contains(cmd, "-t") {
+			t.Errorf("command without a target: %q", cmd)
+		}
+	}
+
+	// One new-session and four splits: Terminal, PR Status, Changed Files
+	// and Plans, each running in the worktree
+	var commands []string
+	for i, a := range args {
+		if i == 0 || args[i-1] == ";" {
+			commands = append(commands, a)
+		}
+	}
+	if n := strings.Count(strings.Join(commands, " "), "split-window"); n != 4 {
+		t.Errorf("%d splits, want 4: %q", n, args)
+	}
+	for _, script := range []string{"/tmp/scripts/plans-watcher.sh", "/tmp/scripts/git-diff-picker.sh", "/tmp/scripts/pr-status.sh"} {
+		if !slices.Contains(args, script) {
+			t.Errorf("args do not run %s: %q", script, args)
+		}
+	}
+	for _, title := range []string{"Plans", "Claude Code", "Changed Files", "Terminal", "PR Status"} {
+		if !slices.Contains(args, title) {
+			t.Errorf("no pane titled %q", title)
+		}
+	}
+}
+
+func TestTmuxSessionEnv(t *testing.T) {
+	ec := &pipeline.ExecutionContext{
+		Profile: profile.Profile{Environment: profile.EnvironmentHost},
+		EnvVars: map[string]string{"FOO": "bar"},
+	}
+	if env := tmuxSessionEnv(ec); !slices.Contains(env, "FOO=bar") {
+		t.Errorf("tmuxSessionEnv() = %q, want FOO=bar", env)
+	}
+
+	// In docker mode the values are for the docker command of the Claude
+	// Code pane, which passes them by name
+	ec.Profile.Environment = profile.EnvironmentDocker
+	ec.WorkDir = "/wt"
+	if env := tmuxSessionEnv(ec); !slices.Contains(env, "FOO=bar") || !slices.Contains(env, "HOST_WORKSPACE=/wt") {
+		t.Errorf("tmuxSessionEnv() = %q in docker mode, want FOO and HOST_WORKSPACE", env)
+	}
+	if cmd := claudePaneCommand(ec); strings.Contains(cmd, "bar") {
+		t.Errorf("claudePaneCommand() = %q, contains the value of FOO", cmd)
+	}
+}
+
+func TestTmuxEnvScript(t *testing.T) {
+	got := tmuxEnvScript("dev", []string{"A=x y", `B=say "hi" to $USER\n`, "C=l1\nl2"})
+	want := `set-environment -t "=dev:" "A" "x y"
+set-environment -t "=dev:" "B" "say \"hi\" to \$USER\\n"
+set-environment -t "=dev:" "C" "l1\nl2"
+`
+	if got != want {
+		t.Errorf("tmuxEnvScript() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTmuxCloseHookArgs(t *testing.T) {
+	got := tmuxCloseHookArgs(3, "/tmp/aw-tmux-1")
+	want := []string{"set-hook", "-g", "session-closed[3]",
+		`if-shell -F '#{==:#{hook_session},$3}' { wait-for -S aw-session-3 ; run-shell "rm -rf /tmp/aw-tmux-1" ; set-hook -gu session-closed[3] }`}
+	if !slices.Equal(got, want) {
+		t.Errorf("tmuxCloseHookArgs() =\n%q\nwant\n%q", got, want)
+	}
+}
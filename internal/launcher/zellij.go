@@ -12,6 +12,7 @@ import (
 
 	"github.com/hiragram/agent-workspace/internal/docker"
 	"github.com/hiragram/agent-workspace/internal/pipeline"
+	"github.com/hiragram/agent-workspace/internal/proc"
 	"github.com/hiragram/agent-workspace/internal/profile"
 )
 
@@ -29,6 +30,25 @@ func (l *ZellijLauncher) Launch(_ context.Context, ec *pipeline.ExecutionContext
 		return fmt.Errorf("zellij is not installed (brew install zellij)")
 	}
 
+	// Zellij session names cannot contain "/" (e.g. branch "feat/login")
+	sessionName := workspaceSessionName(ec)
+
+	// Reattach to a session left running by an earlier launch when resuming
+	// a worktree. An exited session is deleted instead of being resurrected,
+	// since its panes refer to the previous launch's temp files.
+	if exited, ok := listZellijSessions()[sessionName]; ok {
+		if !exited {
+			if err := checkZellijReattach(ec, sessionName); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Attaching to zellij session: %s\n", sessionName)
+			return l.attachZellij(ec, sessionName)
+		}
+		if err := exec.Command("zellij", "delete-session", sessionName).Run(); err != nil {
+			return fmt.Errorf("deleting exited zellij session %s: %w", sessionName, err)
+		}
+	}
+
 	// Prepare temp directory with scripts and layout
 	tmpDir, cleanup, err := l.prepareFiles(ec)
 	if err != nil {
@@ -37,13 +57,8 @@ func (l *ZellijLauncher) Launch(_ context.Context, ec *pipeline.ExecutionContext
 	defer cleanup()
 
 	// Launch zellij
-	sessionName := ec.WorktreeBranch
-	if sessionName == "" {
-		sessionName = ec.ProfileName
-	}
-
 	fmt.Fprintf(os.Stderr, "Launching zellij session: %s\n", sessionName)
-	return l.launchZellij(ec.WorkDir, tmpDir, sessionName)
+	return l.launchZellij(ec, tmpDir, sessionName)
 }
 
 func (l *ZellijLauncher) prepareFiles(ec *pipeline.ExecutionContext) (string, func(), error) {
@@ -54,27 +69,13 @@ func (l *ZellijLauncher) prepareFiles(ec *pipeline.ExecutionContext) (string, fu
 	cleanupFn := func() { _ = os.RemoveAll(tmpDir) }
 
 	scriptsDir := filepath.Join(tmpDir, "scripts")
-	if err := os.MkdirAll(scriptsDir, 0755); err != nil {
+	if err := writeHelperScripts(scriptsDir); err != nil {
 		cleanupFn()
-		return "", nil, fmt.Errorf("creating scripts dir: %w", err)
-	}
-
-	// Write shell scripts
-	scripts := map[string][]byte{
-		"plans-watcher.sh":   plansWatcherSh,
-		"git-diff-picker.sh": gitDiffPickerSh,
-		"pr-status.sh":       prStatusSh,
-	}
-	for name, content := range scripts {
-		path := filepath.Join(scriptsDir, name)
-		if err := os.WriteFile(path, content, 0755); err != nil {
-			cleanupFn()
-			return "", nil, fmt.Errorf("writing %s: %w", name, err)
-		}
+		return "", nil, err
 	}
 
 	// Build Claude command based on environment
-	claudeCmd := l.buildClaudeCommand(ec)
+	claudeCmd := claudePaneCommand(ec)
 
 	// Render and write layout template
 	tmpl, err := template.New("layout").Parse(string(layoutKdlTmpl))
@@ -101,55 +102,67 @@ func (l *ZellijLauncher) prepareFiles(ec *pipeline.ExecutionContext) (string, fu
 	return tmpDir, cleanupFn, nil
 }
 
-func (l *ZellijLauncher) buildClaudeCommand(ec *pipeline.ExecutionContext) string {
-	switch ec.Profile.Environment {
-	case profile.EnvironmentDocker:
-		// Build docker run command directly using the image already built
-		// by the DockerStage, so we don't re-run the pipeline with a
-		// different profile that would lose custom Dockerfile settings.
-		envVars := make(map[string]string, len(ec.EnvVars)+2)
-		for k, v := range ec.EnvVars {
-			envVars[k] = v
-		}
-		envVars["HOST_CLAUDE_HOME"] = claudeHomePath(ec.HomeDir)
-		envVars["HOST_WORKSPACE"] = ec.WorkDir
-
-		runConfig := docker.RunConfig{
-			ImageName: ec.DockerImage,
-			Mounts:    ec.DockerMounts,
-			EnvVars:   envVars,
-			WorkDir:   ec.WorkDir,
-			Command:   []string{"claude", "--dangerously-skip-permissions"},
-		}
-		args := docker.BuildRunArgs(runConfig)
-		return "docker " + shellJoin(args)
-	default:
-		// Host mode: just run claude directly
-		return "claude"
-	}
-}
-
-// shellJoin quotes arguments for safe shell embedding.
-func shellJoin(args []string) string {
-	quoted := make([]string, len(args))
-	for i, a := range args {
-		if strings.ContainsAny(a, " \t\n\"'\\$`!#&|;(){}") {
-			quoted[i] = "'" + strings.ReplaceAll(a, "'", "'\"'\"'") + "'"
-		} else {
-			quoted[i] = a
-		}
-	}
-	return strings.Join(quoted, " ")
-}
-
-func (l *ZellijLauncher) launchZellij(workDir, tmpDir, sessionName string) error {
+func (l *ZellijLauncher) launchZellij(ec *pipeline.ExecutionContext, tmpDir, sessionName string) error {
 	layoutPath := filepath.Join(tmpDir, "layout.kdl")
 	cmd := exec.Command("zellij",
 		"--new-session-with-layout", layoutPath,
 		"-s", sessionName)
-	cmd.Dir = workDir
+	cmd.Dir = ec.WorkDir
+	if ec.Profile.Environment == profile.EnvironmentHost {
+		// Host mode: panes (including Claude) inherit the custom env
+		cmd.Env = hostEnv(ec)
+	} else {
+		// Docker mode: the Claude pane passes the env vars into the
+		// container by name
+		cmd.Env = docker.RunEnv(DockerRunConfig(ec, ec.EnvVars, nil))
+	}
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return proc.RunForeground(cmd)
+}
+
+func (l *ZellijLauncher) attachZellij(ec *pipeline.ExecutionContext, sessionName string) error {
+	cmd := exec.Command("zellij", "attach", sessionName)
+	cmd.Dir = ec.WorkDir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return proc.RunForeground(cmd)
+}
+
+// listZellijSessions returns the existing zellij sessions, mapped to whether
+// they have exited.
+// checkZellijReattach returns an error unless the running session called
+// name may be reattached to, which is only when resuming a worktree.
+// Otherwise the session is not this workspace's: e.g. one of another
+// checkout, or of a removed worktree of the same branch, whose panes point
+// at a deleted directory.
+func checkZellijReattach(ec *pipeline.ExecutionContext, name string) error {
+	if ec.Resume {
+		return nil
+	}
+	return fmt.Errorf("zellij session %s is already running (attach to it with `zellij attach %s`, or end it)", name, name)
+}
+
+func listZellijSessions() map[string]bool {
+	// Exits non-zero when there are no sessions
+	out, _ := exec.Command("zellij", "list-sessions", "--no-formatting").Output()
+	return parseZellijSessions(string(out))
+}
+
+// parseZellijSessions parses the output of `zellij list-sessions
+// --no-formatting`, whose lines look like:
+//
+//	name [Created 2h ago] (EXITED - attach to resurrect)
+func parseZellijSessions(out string) map[string]bool {
+	sessions := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		sessions[fields[0]] = strings.Contains(line, "(EXITED")
+	}
+	return sessions
 }
@@ -0,0 +1,76 @@
+package launcher
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hiragram/agent-workspace/internal/pipeline"
+)
+
+func TestParseZellijSessions(t *testing.T) {
+	out := `red-fox-jump [Created 2h 3m ago] (current)
+feat-login [Created 1day ago] (EXITED - attach to resurrect)
+dev [Created 10s ago]
+`
+	want := map[string]bool{
+		"red-fox-jump": false,
+		"feat-login":   true,
+		"dev":          false,
+	}
+	if got := parseZellijSessions(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseZellijSessions() = %v, want %v", got, want)
+	}
+}
+
+func TestParseZellijSessions_NoSessions(t *testing.T) {
+	if got := parseZellijSessions(""); len(got) != 0 {
+		t.Errorf("parseZellijSessions(\"\") = %v, want empty", got)
+	}
+}
+
+func TestCheckZellijReattach(t *testing.T) {
+	// A new worktree of a branch whose old worktree's session still runs
+	ec := &pipeline.ExecutionContext{WorktreeBranch: "foo", WorktreeNew: true}
+	if err := checkZellijReattach(ec, "foo"); err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Errorf("checkZellijReattach() = %v for a new worktree, want an already running error", err)
+	}
+
+	ec = &pipeline.ExecutionContext{ProfileName: "claude"}
+	if err := checkZellijReattach(ec, "claude"); err == nil {
+		t.Error("checkZellijReattach() = nil without a worktree")
+	}
+
+	ec = &pipeline.ExecutionContext{WorktreeBranch: "foo", Resume: true}
+	if err := checkZellijReattach(ec, "foo"); err != nil {
+		t.Errorf("checkZellijReattach() = %v when resuming", err)
+	}
+}
+
+func TestWorkspaceSessionName(t *testing.T) {
+	// Without a worktree, the same profile in two repositories gets two
+	// sessions, named after the repository root even from a subdirectory
+	var names []string
+	for _, repo := range []string{"repo-a", "repo-b"} {
+		root := filepath.Join(t.TempDir(), repo)
+		sub := filepath.Join(root, "sub")
+		if err := os.MkdirAll(sub, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+			t.Fatalf("git init: %v\n%s", err, out)
+		}
+		names = append(names, workspaceSessionName(&pipeline.ExecutionContext{ProfileName: "claude", WorkDir: sub}))
+	}
+	if names[0] != "repo-a-claude" || names[1] != "repo-b-claude" {
+		t.Errorf("session names = %q, want repo-a-claude and repo-b-claude", names)
+	}
+
+	ec := &pipeline.ExecutionContext{ProfileName: "claude", WorktreeBranch: "feat/login", RepoRoot: "/src/app"}
+	if got := workspaceSessionName(ec); got != "feat-login" {
+		t.Errorf("workspaceSessionName() = %q for a worktree, want feat-login", got)
+	}
+}
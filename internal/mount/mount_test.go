@@ -216,6 +216,45 @@ func TestBuildMounts_WorktreeAddsMount(t *testing.T) {
 	}
 }
 
+func TestBuildMounts_WorktreeOutsideRepo(t *testing.T) {
+	// Worktree created via worktree.dir outside the main repository,
+	// e.g. ~/worktrees/<repo>/<name>
+	mainRepo := t.TempDir()
+	mainGitDir := filepath.Join(mainRepo, ".git")
+	adminDir := filepath.Join(mainGitDir, "worktrees", "wt")
+	if err := os.MkdirAll(adminDir, 0755); err != nil {
+		t.Fatalf("creating worktree admin dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(adminDir, "commondir"), []byte("../..\n"), 0644); err != nil {
+		t.Fatalf("writing commondir: %v", err)
+	}
+
+	homeDir := t.TempDir()
+	worktreeDir := filepath.Join(homeDir, "worktrees", filepath.Base(mainRepo), "wt")
+	if err := os.MkdirAll(worktreeDir, 0755); err != nil {
+		t.Fatalf("creating worktree dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(worktreeDir, ".git"), []byte("gitdir: "+adminDir+"\n"), 0644); err != nil {
+		t.Fatalf("writing .git file: %v", err)
+	}
+
+	mounts, err := NewBuilder().BuildMounts(newTestOpts(homeDir, worktreeDir))
+	if err != nil {
+		t.Fatalf("BuildMounts() error: %v", err)
+	}
+
+	m := findMount(mounts, mainGitDir)
+	if m == nil {
+		t.Fatalf("missing worktree mount for %s", mainGitDir)
+	}
+	if m.Source != mainGitDir {
+		t.Errorf("source = %q, want %q", m.Source, mainGitDir)
+	}
+	if findMount(mounts, worktreeDir) == nil {
+		t.Errorf("missing workspace mount for %s", worktreeDir)
+	}
+}
+
 func TestBuildMounts_NoWorktreeMount_RegularRepo(t *testing.T) {
 	homeDir := t.TempDir()
 	workDir := t.TempDir()
@@ -42,9 +42,18 @@ func DetectWorktree(workDir string) (string, error) {
 		gitdir = filepath.Join(workDir, gitdir)
 	}
 
-	// gitdir points to .git/worktrees/<name>
-	// Go up 2 levels to get the main .git directory
-	mainGitDir := filepath.Clean(filepath.Join(gitdir, "..", ".."))
+	// gitdir points to .git/worktrees/<name>, whose commondir file holds the
+	// path to the main .git directory. This also holds when the worktree
+	// lives outside the main repository. If commondir is missing, go up
+	// 2 levels to get the main .git directory.
+	mainGitDir := filepath.Join(gitdir, "..", "..")
+	if commondir, err := os.ReadFile(filepath.Join(gitdir, "commondir")); err == nil {
+		mainGitDir = strings.TrimSpace(string(commondir))
+		if !filepath.IsAbs(mainGitDir) {
+			mainGitDir = filepath.Join(gitdir, mainGitDir)
+		}
+	}
+	mainGitDir = filepath.Clean(mainGitDir)
 
 	// Resolve to absolute path
 	mainGitDir, err = filepath.Abs(mainGitDir)
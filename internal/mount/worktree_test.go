@@ -2,6 +2,7 @@ package mount
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 )
@@ -108,6 +109,40 @@ func TestDetectWorktree_RelativeGitdir(t *testing.T) {
 	}
 }
 
+func TestDetectWorktree_CommondirFile(t *testing.T) {
+	// The worktree admin dir is not two levels below the main .git dir, so
+	// the main .git dir can only be found via the commondir file.
+	baseDir := t.TempDir()
+
+	mainGitDir := filepath.Join(baseDir, "main-repo", ".git")
+	adminDir := filepath.Join(baseDir, "admin", "wt")
+	if err := os.MkdirAll(mainGitDir, 0755); err != nil {
+		t.Fatalf("creating main git dir: %v", err)
+	}
+	if err := os.MkdirAll(adminDir, 0755); err != nil {
+		t.Fatalf("creating admin dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(adminDir, "commondir"), []byte("../../main-repo/.git\n"), 0644); err != nil {
+		t.Fatalf("writing commondir: %v", err)
+	}
+
+	worktreeDir := filepath.Join(baseDir, "elsewhere", "wt")
+	if err := os.MkdirAll(worktreeDir, 0755); err != nil {
+		t.Fatalf("creating worktree dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(worktreeDir, ".git"), []byte("gitdir: "+adminDir+"\n"), 0644); err != nil {
+		t.Fatalf("writing .git file: %v", err)
+	}
+
+	result, err := DetectWorktree(worktreeDir)
+	if err != nil {
+		t.Fatalf("DetectWorktree() error: %v", err)
+	}
+	if result != mainGitDir {
+		t.Errorf("DetectWorktree() = %q, want %q", result, mainGitDir)
+	}
+}
+
 func TestDetectWorktree_InvalidGitFile(t *testing.T) {
 	workDir := t.TempDir()
 
@@ -148,3 +183,33 @@ func TestIsSubpath(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectWorktree_SparseWorktree(t *testing.T) {
+	// A sparse checkout keeps its settings in the worktree's admin dir and
+	// enables extensions.worktreeConfig; the .git file is unchanged.
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mainRepo := filepath.Join(base, "main-repo")
+	worktreeDir := filepath.Join(base, "wt")
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main", mainRepo},
+		{"-C", mainRepo, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+		{"-C", mainRepo, "worktree", "add", "-q", "--no-checkout", "-b", "sparse", worktreeDir},
+		{"-C", worktreeDir, "sparse-checkout", "set", "--cone", "--", "src"},
+		{"-C", worktreeDir, "checkout", "-q"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	result, err := DetectWorktree(worktreeDir)
+	if err != nil {
+		t.Fatalf("DetectWorktree() error: %v", err)
+	}
+	if want := filepath.Join(mainRepo, ".git"); result != want {
+		t.Errorf("DetectWorktree() = %q, want %q", result, want)
+	}
+}
@@ -12,18 +12,47 @@ type ExecutionContext struct {
 	ProfileName string
 	HomeDir     string
 	OrigWorkDir string // directory where `aw` was invoked
+	Branch      string // branch name given with --branch (empty to generate one)
+	Ticket      string // ticket ID given with --ticket, for {{ticket}} in branch templates
+	PR          int    // pull request number given with --pr (0 if none)
+	Resume      bool   // reuse an existing worktree instead of creating one
+	ResumeName  string // name of the worktree to reuse (empty for the most recently used)
+	DefaultBase string // the config's default-base, for profiles without worktree.base (empty to detect)
 
 	// Set by WorktreeStage (if applicable)
 	WorkDir        string // effective working directory (may be worktree path)
 	WorktreePath   string // empty if no worktree was created
 	WorktreeBranch string // branch name of the created worktree
 	RepoRoot       string // git repository root path
+	WorktreeNew    bool   // true if the worktree was created in this run (not resumed)
 
 	// Set by DockerStage (if applicable)
 	DockerImage  string
 	DockerMounts []docker.Mount
 	DockerVolume string
 
-	// Set by EnvStage (if applicable)
-	EnvVars map[string]string // custom env vars to pass into Docker container
+	// Set by EnvStage
+	EnvVars map[string]string // custom env vars to pass into the launched process
+
+	// Set by Pipeline for StartStep
+	stage     string
+	observers []Observer
+}
+
+// WorkspaceEnv returns the AW_* variables describing the workspace.
+// Variables with no value (e.g. AW_WORKTREE_PATH without a worktree) are omitted.
+func (ec *ExecutionContext) WorkspaceEnv() map[string]string {
+	env := map[string]string{
+		"AW_WORKTREE_PATH":   ec.WorktreePath,
+		"AW_WORKTREE_BRANCH": ec.WorktreeBranch,
+		"AW_REPO_ROOT":       ec.RepoRoot,
+		"AW_PROFILE_NAME":    ec.ProfileName,
+		"AW_ENVIRONMENT":     string(ec.Profile.Environment),
+	}
+	for k, v := range env {
+		if v == "" {
+			delete(env, k)
+		}
+	}
+	return env
 }
@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+func TestExecutionContext_WorkspaceEnv(t *testing.T) {
+	ec := &ExecutionContext{
+		Profile:        profile.Profile{Environment: profile.EnvironmentHost},
+		ProfileName:    "my-profile",
+		WorktreePath:   "/repo/worktrees/foo",
+		WorktreeBranch: "foo",
+		RepoRoot:       "/repo",
+	}
+
+	env := ec.WorkspaceEnv()
+
+	want := map[string]string{
+		"AW_WORKTREE_PATH":   "/repo/worktrees/foo",
+		"AW_WORKTREE_BRANCH": "foo",
+		"AW_REPO_ROOT":       "/repo",
+		"AW_PROFILE_NAME":    "my-profile",
+		"AW_ENVIRONMENT":     "host",
+	}
+	if len(env) != len(want) {
+		t.Fatalf("got %d vars (%v), want %d", len(env), env, len(want))
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("%s = %q, want %q", k, env[k], v)
+		}
+	}
+}
+
+func TestExecutionContext_WorkspaceEnv_OmitsEmpty(t *testing.T) {
+	ec := &ExecutionContext{
+		Profile:     profile.Profile{Environment: profile.EnvironmentDocker},
+		ProfileName: "claude",
+	}
+
+	env := ec.WorkspaceEnv()
+
+	if _, ok := env["AW_WORKTREE_PATH"]; ok {
+		t.Error("AW_WORKTREE_PATH should be omitted without a worktree")
+	}
+	if env["AW_PROFILE_NAME"] != "claude" {
+		t.Errorf("AW_PROFILE_NAME = %q, want %q", env["AW_PROFILE_NAME"], "claude")
+	}
+}
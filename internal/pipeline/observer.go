@@ -0,0 +1,187 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// EventType is the kind of a pipeline event.
+type EventType string
+
+const (
+	EventStart  EventType = "start"
+	EventFinish EventType = "finish"
+	EventError  EventType = "error"
+)
+
+// Event describes the start or end of a stage, or of a step within a stage
+// (e.g. "fetch" in the worktree stage, "build" in the docker stage).
+type Event struct {
+	Time     time.Time
+	Type     EventType
+	Stage    string
+	Step     string        // empty for stage events
+	Duration time.Duration // set for finish and error events
+	Err      error         // set for error events
+}
+
+// Observer receives pipeline events. Observe is called synchronously from
+// the pipeline and should return quickly.
+type Observer interface {
+	Observe(e Event)
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(e Event)
+
+func (f ObserverFunc) Observe(e Event) { f(e) }
+
+// StartStep reports the start of a step within the running stage and
+// returns a function that reports its end. Stages use it to break down
+// their duration:
+//
+//	done := ec.StartStep("fetch")
+//	err := fetch()
+//	done(err)
+func (ec *ExecutionContext) StartStep(name string) func(err error) {
+	stage := ec.stage
+	emit(ec.observers, Event{Type: EventStart, Stage: stage, Step: name})
+	start := time.Now()
+	return func(err error) {
+		emitEnd(ec.observers, stage, name, start, err)
+	}
+}
+
+func emit(observers []Observer, e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	for _, o := range observers {
+		o.Observe(e)
+	}
+}
+
+func emitEnd(observers []Observer, stage, step string, start time.Time, err error) {
+	e := Event{Type: EventFinish, Stage: stage, Step: step, Duration: time.Since(start)}
+	if err != nil {
+		e.Type = EventError
+		e.Err = err
+	}
+	emit(observers, e)
+}
+
+// jsonEvent is the NDJSON representation of an Event.
+type jsonEvent struct {
+	Time       time.Time `json:"time"`
+	Type       EventType `json:"type"`
+	Stage      string    `json:"stage"`
+	Step       string    `json:"step,omitempty"`
+	DurationMS *float64  `json:"duration_ms,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// JSONEventWriter writes events as newline-delimited JSON.
+type JSONEventWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONEventWriter creates an observer that writes NDJSON to w.
+func NewJSONEventWriter(w io.Writer) *JSONEventWriter {
+	return &JSONEventWriter{w: w}
+}
+
+func (j *JSONEventWriter) Observe(e Event) {
+	je := jsonEvent{Time: e.Time, Type: e.Type, Stage: e.Stage, Step: e.Step}
+	if e.Type != EventStart {
+		ms := float64(e.Duration.Microseconds()) / 1000
+		je.DurationMS = &ms
+	}
+	if e.Err != nil {
+		je.Error = e.Err.Error()
+	}
+	b, err := json.Marshal(je)
+	if err != nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, _ = j.w.Write(append(b, '\n'))
+}
+
+// Timings collects the durations of stages and steps for a report.
+type Timings struct {
+	mu      sync.Mutex
+	start   time.Time
+	entries []timing
+}
+
+type timing struct {
+	stage    string
+	step     string
+	duration time.Duration
+	running  bool
+	failed   bool
+}
+
+func (t *Timings) Observe(e Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.start.IsZero() {
+		t.start = e.Time
+	}
+
+	switch {
+	case e.Type == EventStart && e.Step == "":
+		// Added on start so that a stage is listed before its steps
+		t.entries = append(t.entries, timing{stage: e.Stage, running: true})
+	case e.Type == EventStart:
+		// Steps are added when they end
+	case e.Step == "":
+		for i := len(t.entries) - 1; i >= 0; i-- {
+			if en := &t.entries[i]; en.running && en.stage == e.Stage {
+				en.duration, en.running, en.failed = e.Duration, false, e.Type == EventError
+				break
+			}
+		}
+	default:
+		t.entries = append(t.entries, timing{stage: e.Stage, step: e.Step, duration: e.Duration, failed: e.Type == EventError})
+	}
+}
+
+// Report writes a table of the stage and step durations collected so far.
+// Stages that are still running (e.g. the launch) are left out.
+func (t *Timings) Report(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAGE\tDURATION")
+	for _, en := range t.entries {
+		if en.running {
+			continue
+		}
+		name := en.stage
+		if en.step != "" {
+			name = "  " + en.step
+		}
+		d := formatDuration(en.duration)
+		if en.failed {
+			d += " (failed)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", name, d)
+	}
+	if !t.start.IsZero() {
+		fmt.Fprintf(tw, "total\t%s\n", formatDuration(time.Since(t.start)))
+	}
+	_ = tw.Flush()
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
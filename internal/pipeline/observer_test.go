@@ -0,0 +1,138 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+// stepStage reports a step while it runs.
+type stepStage struct {
+	name, step string
+	err        error
+}
+
+func (s *stepStage) Name() string { return s.name }
+func (s *stepStage) Run(_ context.Context, ec *ExecutionContext) error {
+	done := ec.StartStep(s.step)
+	done(s.err)
+	return s.err
+}
+
+type recordingObserver struct {
+	events []Event
+}
+
+func (r *recordingObserver) Observe(e Event) { r.events = append(r.events, e) }
+
+func (r *recordingObserver) summary() string {
+	var parts []string
+	for _, e := range r.events {
+		name := e.Stage
+		if e.Step != "" {
+			name += "/" + e.Step
+		}
+		parts = append(parts, string(e.Type)+":"+name)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestPipeline_Execute_EmitsEvents(t *testing.T) {
+	obs := &recordingObserver{}
+	p := New(&stepStage{name: "worktree", step: "fetch"}, &stepStage{name: "docker", step: "build", err: fmt.Errorf("boom")})
+	p.Observers = []Observer{obs}
+	p.HookRunner = &mockHookRunner{}
+	ec := &ExecutionContext{Profile: profile.Profile{Hooks: profile.Hooks{
+		profile.HookPreWorktree: {{Run: "make"}},
+	}}}
+
+	if err := p.Execute(context.Background(), ec); err == nil {
+		t.Fatal("Execute() expected error, got nil")
+	}
+
+	want := "start:pre-worktree hooks,start:pre-worktree hooks/make,finish:pre-worktree hooks/make,finish:pre-worktree hooks," +
+		"start:worktree,start:worktree/fetch,finish:worktree/fetch,finish:worktree," +
+		"start:docker,start:docker/build,error:docker/build,error:docker"
+	if got := obs.summary(); got != want {
+		t.Errorf("events =\n%s\nwant\n%s", got, want)
+	}
+	for _, e := range obs.events {
+		if e.Time.IsZero() {
+			t.Errorf("event %+v has no time", e)
+		}
+		if e.Type == EventError && e.Err == nil {
+			t.Errorf("error event %+v has no error", e)
+		}
+	}
+}
+
+func TestJSONEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONEventWriter(&buf)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	w.Observe(Event{Time: now, Type: EventStart, Stage: "docker"})
+	w.Observe(Event{Time: now, Type: EventError, Stage: "docker", Step: "build", Duration: 1500 * time.Millisecond, Err: errors.New("exit status 1")})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	if want := `{"time":"2026-01-02T03:04:05Z","type":"start","stage":"docker"}`; lines[0] != want {
+		t.Errorf("line 1 = %s, want %s", lines[0], want)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &got); err != nil {
+		t.Fatalf("line 2 is not JSON: %v", err)
+	}
+	if got["step"] != "build" || got["duration_ms"] != 1500.0 || got["error"] != "exit status 1" {
+		t.Errorf("line 2 = %s", lines[1])
+	}
+}
+
+func TestTimings_Report(t *testing.T) {
+	timings := &Timings{}
+	now := time.Now()
+	events := []Event{
+		{Type: EventStart, Stage: "worktree"},
+		{Type: EventStart, Stage: "worktree", Step: "fetch"},
+		{Type: EventFinish, Stage: "worktree", Step: "fetch", Duration: 900 * time.Millisecond},
+		{Type: EventFinish, Stage: "worktree", Duration: 1200 * time.Millisecond},
+		{Type: EventStart, Stage: "docker"},
+		{Type: EventError, Stage: "docker", Step: "build", Duration: 2 * time.Second},
+		{Type: EventError, Stage: "docker", Duration: 2 * time.Second},
+		{Type: EventStart, Stage: "launch"},
+	}
+	for _, e := range events {
+		e.Time = now
+		timings.Observe(e)
+	}
+
+	var buf bytes.Buffer
+	timings.Report(&buf)
+	out := buf.String()
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	wantPrefixes := []string{"STAGE", "worktree", "  fetch", "docker", "  build", "total"}
+	if len(lines) != len(wantPrefixes) {
+		t.Fatalf("report has %d lines, want %d:\n%s", len(lines), len(wantPrefixes), out)
+	}
+	for i, prefix := range wantPrefixes {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d = %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+	if !strings.Contains(lines[1], "1.2s") || !strings.Contains(lines[2], "900ms") {
+		t.Errorf("unexpected durations:\n%s", out)
+	}
+	if !strings.Contains(lines[3], "2s (failed)") {
+		t.Errorf("failed stage not marked:\n%s", out)
+	}
+}
@@ -2,8 +2,12 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
 )
 
 // Stage is a single step in the execution pipeline.
@@ -14,9 +18,59 @@ type Stage interface {
 	Run(ctx context.Context, ec *ExecutionContext) error
 }
 
+// Rollbacker is implemented by stages that can undo their work, e.g. remove
+// a worktree they created. When a stage fails, Rollback is called on it and
+// on every stage before it, in reverse order. Rollback must cope with a
+// partially completed Run.
+type Rollbacker interface {
+	Rollback(ctx context.Context, ec *ExecutionContext) error
+}
+
+// noRollbackError marks an error that must not trigger a rollback.
+type noRollbackError struct {
+	err error
+}
+
+func (e *noRollbackError) Error() string { return e.err.Error() }
+func (e *noRollbackError) Unwrap() error { return e.err }
+
+// NoRollback wraps err so that the pipeline keeps what earlier stages
+// created, e.g. when the launched process itself exits with an error. Such
+// an error is returned by Execute, but does not run the on-error hooks.
+func NoRollback(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &noRollbackError{err: err}
+}
+
+// IsNoRollback reports whether err was wrapped with NoRollback.
+func IsNoRollback(err error) bool {
+	var noRollback *noRollbackError
+	return errors.As(err, &noRollback)
+}
+
+// HookRunner runs a single lifecycle hook command.
+type HookRunner interface {
+	// RunHook runs hook for event. env holds AW_* variables describing the
+	// event, to be added to the command's environment.
+	RunHook(ctx context.Context, ec *ExecutionContext, event string, hook profile.HookCommand, env map[string]string) error
+}
+
 // Pipeline executes a sequence of stages.
 type Pipeline struct {
 	stages []Stage
+
+	// HookRunner runs the profile's lifecycle hooks (pre-<stage>,
+	// post-<stage> and on-error). If nil, hooks are not run.
+	HookRunner HookRunner
+
+	// KeepOnFailure disables rolling back stages when a later stage fails.
+	KeepOnFailure bool
+
+	// Observers receive start, finish and error events of stages, steps
+	// and hooks.
+	Observers []Observer
 }
 
 // New creates a pipeline from the given stages.
@@ -24,12 +78,141 @@ func New(stages ...Stage) *Pipeline {
 	return &Pipeline{stages: stages}
 }
 
-// Execute runs all stages in sequence.
+// ErrInterrupted is returned by Execute when its context is canceled, e.g.
+// because aw received SIGINT.
+var ErrInterrupted = errors.New("interrupted")
+
+// Execute runs all stages in sequence, with the profile's pre-<stage> and
+// post-<stage> hooks around each of them. If a stage or hook fails, the
+// on-error hooks are run, the stages run so far are rolled back (see
+// Rollbacker) and the error is returned. A failing post-launch hook is
+// reported the same way, but rolls nothing back. A stage error wrapped with
+// NoRollback is returned as is: the stage's post hooks run and neither the
+// on-error hooks nor rollbacks do.
+//
+// If ctx is canceled, the running stage is interrupted and no further
+// stages are started. The on-error hooks, rollbacks and post-launch hooks
+// still run, since they clean up after the workspace.
 func (p *Pipeline) Execute(ctx context.Context, ec *ExecutionContext) error {
-	for _, s := range p.stages {
+	for i, s := range p.stages {
+		if ctx.Err() != nil {
+			return p.fail(ctx, ec, i, fmt.Errorf("%s: %w", s.Name(), ErrInterrupted))
+		}
+		if err := p.runHooks(ctx, ec, "pre-"+s.Name(), nil); err != nil {
+			return p.fail(ctx, ec, i, err)
+		}
 		fmt.Fprintf(os.Stderr, "[%s]\n", s.Name())
-		if err := s.Run(ctx, ec); err != nil {
-			return fmt.Errorf("%s: %w", s.Name(), err)
+		if err := p.runStage(ctx, ec, s); err != nil {
+			err = fmt.Errorf("%s: %w", s.Name(), err)
+			if IsNoRollback(err) {
+				// The stage did its work, e.g. the session ran and exited
+				// with a non-zero status: that is not a pipeline failure
+				if hookErr := p.runHooks(ctx, ec, "post-"+s.Name(), nil); hookErr != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", hookErr)
+				}
+				return err
+			}
+			if ctx.Err() != nil {
+				err = fmt.Errorf("%w: %w", ErrInterrupted, err)
+			}
+			return p.fail(ctx, ec, i, err)
+		}
+		if err := p.runHooks(ctx, ec, "post-"+s.Name(), nil); err != nil {
+			if s.Name() == launchStageName {
+				// The workspace has been used by then; a failing teardown
+				// hook must not remove it
+				p.runOnError(ctx, ec, i, err)
+				return err
+			}
+			return p.fail(ctx, ec, i, err)
+		}
+	}
+	return nil
+}
+
+// launchStageName is the name of the stage that starts the user's session.
+// Nothing is rolled back once it has run.
+const launchStageName = "launch"
+
+// runStage runs s and reports it to the observers.
+func (p *Pipeline) runStage(ctx context.Context, ec *ExecutionContext, s Stage) error {
+	ec.stage, ec.observers = s.Name(), p.Observers
+	emit(p.Observers, Event{Type: EventStart, Stage: s.Name()})
+	start := time.Now()
+	err := s.Run(ctx, ec)
+	emitEnd(p.Observers, s.Name(), "", start, err)
+	return err
+}
+
+// fail runs the on-error hooks for err, rolls back the stages up to and
+// including the failed one and returns err.
+func (p *Pipeline) fail(ctx context.Context, ec *ExecutionContext, failed int, err error) error {
+	ctx = context.WithoutCancel(ctx)
+	p.runOnError(ctx, ec, failed, err)
+
+	if p.KeepOnFailure || IsNoRollback(err) {
+		return err
+	}
+	for i := failed; i >= 0; i-- {
+		r, ok := p.stages[i].(Rollbacker)
+		if !ok {
+			continue
+		}
+		if rbErr := r.Rollback(ctx, ec); rbErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: rolling back %s: %v\n", p.stages[i].Name(), rbErr)
+		}
+	}
+	return err
+}
+
+// runOnError runs the on-error hooks for err, the failure of stage number
+// failed or its hooks. A failing on-error hook only prints a warning.
+func (p *Pipeline) runOnError(ctx context.Context, ec *ExecutionContext, failed int, err error) {
+	env := map[string]string{
+		"AW_FAILED_STAGE": p.stages[failed].Name(),
+		"AW_ERROR":        err.Error(),
+	}
+	if hookErr := p.runHooks(context.WithoutCancel(ctx), ec, profile.HookOnError, env); hookErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", hookErr)
+	}
+}
+
+// runHooks runs the hooks configured for event in order. A failing hook
+// stops the remaining ones unless it has continue-on-error set. The hooks
+// are reported to the observers as a stage named "<event> hooks", with a
+// step per command.
+func (p *Pipeline) runHooks(ctx context.Context, ec *ExecutionContext, event string, env map[string]string) (err error) {
+	hooks := ec.Profile.Hooks[event]
+	if p.HookRunner == nil || len(hooks) == 0 {
+		return nil
+	}
+	if event == profile.HookPostLaunch {
+		// Tear down after the session even if aw was interrupted during it
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	group := event + " hooks"
+	emit(p.Observers, Event{Type: EventStart, Stage: group})
+	start := time.Now()
+	defer func() { emitEnd(p.Observers, group, "", start, err) }()
+
+	for _, h := range hooks {
+		hookEnv := map[string]string{"AW_HOOK_EVENT": event}
+		for k, v := range env {
+			hookEnv[k] = v
+		}
+
+		fmt.Fprintf(os.Stderr, "Running %s hook: %s\n", event, h.Run)
+		emit(p.Observers, Event{Type: EventStart, Stage: group, Step: h.Run})
+		hookStart := time.Now()
+		hookErr := p.HookRunner.RunHook(ctx, ec, event, h, hookEnv)
+		emitEnd(p.Observers, group, h.Run, hookStart, hookErr)
+		if hookErr != nil {
+			if h.ContinueOnError {
+				fmt.Fprintf(os.Stderr, "Warning: %s hook %q failed: %v\n", event, h.Run, hookErr)
+				continue
+			}
+			return fmt.Errorf("%s hook %q: %w", event, h.Run, hookErr)
 		}
 	}
 	return nil
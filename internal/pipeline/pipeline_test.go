@@ -2,9 +2,12 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
 )
 
 // mockStage is a test helper that records its execution.
@@ -93,3 +96,316 @@ func TestPipeline_Stages(t *testing.T) {
 		t.Errorf("Stages() returned wrong stages")
 	}
 }
+
+// mockHookRunner records the hooks it runs, failing those listed in fail.
+type mockHookRunner struct {
+	calls   []string
+	envs    []map[string]string
+	ctxErrs []error
+	fail    map[string]bool
+}
+
+func (r *mockHookRunner) RunHook(ctx context.Context, _ *ExecutionContext, event string, hook profile.HookCommand, env map[string]string) error {
+	r.calls = append(r.calls, event+":"+hook.Run)
+	r.envs = append(r.envs, env)
+	r.ctxErrs = append(r.ctxErrs, ctx.Err())
+	if r.fail[hook.Run] {
+		return fmt.Errorf("exit status 1")
+	}
+	return nil
+}
+
+func TestPipeline_Execute_RunsHooksAroundStages(t *testing.T) {
+	s1 := &mockStage{name: "worktree"}
+	s2 := &mockStage{name: "launch"}
+	runner := &mockHookRunner{}
+
+	p := New(s1, s2)
+	p.HookRunner = runner
+	ec := &ExecutionContext{Profile: profile.Profile{Hooks: profile.Hooks{
+		profile.HookPreWorktree:  {{Run: "a"}, {Run: "b"}},
+		profile.HookPostWorktree: {{Run: "c"}},
+		profile.HookPostLaunch:   {{Run: "d"}},
+		profile.HookOnError:      {{Run: "e"}},
+	}}}
+
+	if err := p.Execute(context.Background(), ec); err != nil {
+		t.Fatalf("Execute() unexpected error: %v", err)
+	}
+
+	want := []string{"pre-worktree:a", "pre-worktree:b", "post-worktree:c", "post-launch:d"}
+	if strings.Join(runner.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("hooks run = %v, want %v", runner.calls, want)
+	}
+	if got := runner.envs[0]["AW_HOOK_EVENT"]; got != "pre-worktree" {
+		t.Errorf("AW_HOOK_EVENT = %q, want pre-worktree", got)
+	}
+}
+
+func TestPipeline_Execute_FailingHookStopsPipeline(t *testing.T) {
+	s1 := &mockStage{name: "worktree"}
+	runner := &mockHookRunner{fail: map[string]bool{"a": true}}
+
+	p := New(s1)
+	p.HookRunner = runner
+	ec := &ExecutionContext{Profile: profile.Profile{Hooks: profile.Hooks{
+		profile.HookPreWorktree: {{Run: "a"}, {Run: "b"}},
+		profile.HookOnError:     {{Run: "e"}},
+	}}}
+
+	err := p.Execute(context.Background(), ec)
+	if err == nil || !strings.Contains(err.Error(), `pre-worktree hook "a"`) {
+		t.Fatalf("Execute() error = %v, want pre-worktree hook error", err)
+	}
+	if s1.ran {
+		t.Error("stage should not run after its pre hook failed")
+	}
+
+	want := []string{"pre-worktree:a", "on-error:e"}
+	if strings.Join(runner.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("hooks run = %v, want %v", runner.calls, want)
+	}
+}
+
+func TestPipeline_Execute_ContinueOnError(t *testing.T) {
+	s1 := &mockStage{name: "worktree"}
+	runner := &mockHookRunner{fail: map[string]bool{"a": true}}
+
+	p := New(s1)
+	p.HookRunner = runner
+	ec := &ExecutionContext{Profile: profile.Profile{Hooks: profile.Hooks{
+		profile.HookPreWorktree: {{Run: "a", ContinueOnError: true}, {Run: "b"}},
+	}}}
+
+	if err := p.Execute(context.Background(), ec); err != nil {
+		t.Fatalf("Execute() unexpected error: %v", err)
+	}
+	if !s1.ran {
+		t.Error("stage should run when the failing hook has continue-on-error")
+	}
+	if len(runner.calls) != 2 {
+		t.Errorf("hooks run = %v, want both hooks", runner.calls)
+	}
+}
+
+func TestPipeline_Execute_OnErrorHookEnv(t *testing.T) {
+	s1 := &mockStage{name: "docker", err: fmt.Errorf("boom")}
+	runner := &mockHookRunner{fail: map[string]bool{"e": true}}
+
+	p := New(s1)
+	p.HookRunner = runner
+	ec := &ExecutionContext{Profile: profile.Profile{Hooks: profile.Hooks{
+		profile.HookOnError: {{Run: "e"}},
+	}}}
+
+	// A failing on-error hook does not replace the stage's error
+	err := p.Execute(context.Background(), ec)
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("Execute() error = %v, want stage error", err)
+	}
+
+	if len(runner.envs) != 1 {
+		t.Fatalf("hooks run = %v, want one on-error hook", runner.calls)
+	}
+	env := runner.envs[0]
+	if env["AW_FAILED_STAGE"] != "docker" {
+		t.Errorf("AW_FAILED_STAGE = %q, want docker", env["AW_FAILED_STAGE"])
+	}
+	if env["AW_ERROR"] != "docker: boom" {
+		t.Errorf("AW_ERROR = %q, want %q", env["AW_ERROR"], "docker: boom")
+	}
+	if env["AW_HOOK_EVENT"] != "on-error" {
+		t.Errorf("AW_HOOK_EVENT = %q, want on-error", env["AW_HOOK_EVENT"])
+	}
+}
+
+// rollbackStage is a mockStage that records rollbacks into a shared log.
+type rollbackStage struct {
+	mockStage
+	log *[]string
+}
+
+func (s *rollbackStage) Rollback(_ context.Context, _ *ExecutionContext) error {
+	*s.log = append(*s.log, s.name)
+	return nil
+}
+
+func TestPipeline_Execute_RollsBackInReverseOrder(t *testing.T) {
+	var log []string
+	s1 := &rollbackStage{mockStage: mockStage{name: "stage-1"}, log: &log}
+	s2 := &mockStage{name: "stage-2"}
+	s3 := &rollbackStage{mockStage: mockStage{name: "stage-3", err: fmt.Errorf("boom")}, log: &log}
+	s4 := &rollbackStage{mockStage: mockStage{name: "stage-4"}, log: &log}
+
+	p := New(s1, s2, s3, s4)
+	if err := p.Execute(context.Background(), &ExecutionContext{}); err == nil {
+		t.Fatal("Execute() expected error, got nil")
+	}
+
+	// The failed stage is rolled back too; stages that never ran are not
+	want := []string{"stage-3", "stage-1"}
+	if strings.Join(log, ",") != strings.Join(want, ",") {
+		t.Errorf("rolled back %v, want %v", log, want)
+	}
+}
+
+func TestPipeline_Execute_NoRollback(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		keep bool
+	}{
+		{"keep on failure", fmt.Errorf("boom"), true},
+		{"NoRollback error", NoRollback(fmt.Errorf("exit status 1")), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			s1 := &rollbackStage{mockStage: mockStage{name: "stage-1"}, log: &log}
+			s2 := &mockStage{name: "stage-2", err: tt.err}
+
+			p := New(s1, s2)
+			p.KeepOnFailure = tt.keep
+			err := p.Execute(context.Background(), &ExecutionContext{})
+			if err == nil || !strings.Contains(err.Error(), tt.err.Error()) {
+				t.Fatalf("Execute() error = %v, want %v", err, tt.err)
+			}
+			if len(log) != 0 {
+				t.Errorf("rolled back %v, want nothing", log)
+			}
+		})
+	}
+}
+
+func TestPipeline_Execute_LaunchExitErrorRunsPostLaunchHooks(t *testing.T) {
+	launch := &mockStage{name: "launch", err: NoRollback(fmt.Errorf("exit status 2"))}
+	runner := &mockHookRunner{}
+
+	p := New(launch)
+	p.HookRunner = runner
+	ec := &ExecutionContext{Profile: profile.Profile{Hooks: profile.Hooks{
+		profile.HookPostLaunch: {{Run: "d"}},
+		profile.HookOnError:    {{Run: "e"}},
+	}}}
+
+	err := p.Execute(context.Background(), ec)
+	if err == nil || !strings.Contains(err.Error(), "exit status 2") {
+		t.Fatalf("Execute() error = %v, want the exit status", err)
+	}
+	want := []string{"post-launch:d"}
+	if strings.Join(runner.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("hooks run = %v, want %v", runner.calls, want)
+	}
+}
+
+func TestPipeline_Execute_FailingPostLaunchHookKeepsWorktree(t *testing.T) {
+	var log []string
+	wt := &rollbackStage{mockStage: mockStage{name: "worktree"}, log: &log}
+	launch := &rollbackStage{mockStage: mockStage{name: "launch"}, log: &log}
+	runner := &mockHookRunner{fail: map[string]bool{"d": true}}
+
+	p := New(wt, launch)
+	p.HookRunner = runner
+	ec := &ExecutionContext{Profile: profile.Profile{Hooks: profile.Hooks{
+		profile.HookPostLaunch: {{Run: "d"}},
+		profile.HookOnError:    {{Run: "e"}},
+	}}}
+
+	err := p.Execute(context.Background(), ec)
+	if err == nil || !strings.Contains(err.Error(), `post-launch hook "d"`) {
+		t.Fatalf("Execute() error = %v, want post-launch hook error", err)
+	}
+	if len(log) != 0 {
+		t.Errorf("rolled back %v, want the worktree kept", log)
+	}
+	want := []string{"post-launch:d", "on-error:e"}
+	if strings.Join(runner.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("hooks run = %v, want %v", runner.calls, want)
+	}
+}
+
+// cancelStage cancels the pipeline's context while it runs, like a signal
+// arriving during a git fetch.
+type cancelStage struct {
+	rollbackStage
+	cancel context.CancelFunc
+}
+
+func (s *cancelStage) Run(ctx context.Context, ec *ExecutionContext) error {
+	s.ran = true
+	s.cancel()
+	return ctx.Err()
+}
+
+func TestPipeline_Execute_Interrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var log []string
+	s1 := &rollbackStage{mockStage: mockStage{name: "stage-1"}, log: &log}
+	s2 := &cancelStage{rollbackStage: rollbackStage{mockStage: mockStage{name: "stage-2"}, log: &log}, cancel: cancel}
+	s3 := &mockStage{name: "stage-3"}
+	runner := &mockHookRunner{}
+
+	p := New(s1, s2, s3)
+	p.HookRunner = runner
+	ec := &ExecutionContext{Profile: profile.Profile{Hooks: profile.Hooks{
+		profile.HookOnError: {{Run: "e"}},
+	}}}
+
+	err := p.Execute(ctx, ec)
+	if !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("Execute() error = %v, want ErrInterrupted", err)
+	}
+	if s3.ran {
+		t.Error("stage-3 should not start after the context was canceled")
+	}
+
+	// Cleanup still runs, with a context that is not canceled
+	want := []string{"stage-2", "stage-1"}
+	if strings.Join(log, ",") != strings.Join(want, ",") {
+		t.Errorf("rolled back %v, want %v", log, want)
+	}
+	if len(runner.ctxErrs) != 1 || runner.ctxErrs[0] != nil {
+		t.Errorf("on-error hook context errors = %v, want one uncanceled run", runner.ctxErrs)
+	}
+}
+
+func TestPipeline_Execute_PostLaunchHooksRunAfterInterrupt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// An interactive launch ignores the canceled context and exits normally
+	launch := &mockStage{name: "launch"}
+	runner := &mockHookRunner{}
+
+	p := New(launch)
+	p.HookRunner = &cancelOnHook{mockHookRunner: runner, event: profile.HookPreLaunch, cancel: cancel}
+	ec := &ExecutionContext{Profile: profile.Profile{Hooks: profile.Hooks{
+		profile.HookPreLaunch:  {{Run: "a"}},
+		profile.HookPostLaunch: {{Run: "b"}},
+	}}}
+
+	if err := p.Execute(ctx, ec); err != nil {
+		t.Fatalf("Execute() unexpected error: %v", err)
+	}
+	if len(runner.ctxErrs) != 2 || runner.ctxErrs[1] != nil {
+		t.Errorf("hook context errors = %v, want post-launch to run uncanceled", runner.ctxErrs)
+	}
+}
+
+// cancelOnHook cancels the context after running the hooks for event.
+type cancelOnHook struct {
+	*mockHookRunner
+	event  string
+	cancel context.CancelFunc
+}
+
+func (r *cancelOnHook) RunHook(ctx context.Context, ec *ExecutionContext, event string, hook profile.HookCommand, env map[string]string) error {
+	err := r.mockHookRunner.RunHook(ctx, ec, event, hook, env)
+	if event == r.event {
+		r.cancel()
+	}
+	return err
+}
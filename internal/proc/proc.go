@@ -0,0 +1,61 @@
+// Package proc runs child processes so that aw can clean up after them when
+// it is interrupted.
+package proc
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// interruptGrace is how long an interrupted command may take to exit before
+// it is killed.
+const interruptGrace = 10 * time.Second
+
+// Interruptible makes cmd, created with exec.CommandContext, receive SIGINT
+// instead of SIGKILL when its context is canceled, so that it can clean up
+// (e.g. git removes lock files, docker stops the build or container). The
+// command is killed if it has not exited after a grace period.
+func Interruptible(cmd *exec.Cmd) *exec.Cmd {
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = interruptGrace
+	return cmd
+}
+
+// RunForeground runs an interactive command attached to the terminal and
+// waits for it to exit.
+//
+// The child shares aw's terminal, so Ctrl-C and Ctrl-\ already reach it
+// directly. aw stays alive for those (like system(3)) and forwards
+// termination signals so the child can exit cleanly and aw can run its
+// cleanup afterwards.
+func RunForeground(cmd *exec.Cmd) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-sigCh:
+				if sig == syscall.SIGTERM || sig == syscall.SIGHUP {
+					_ = cmd.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return cmd.Wait()
+}
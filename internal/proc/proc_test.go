@@ -0,0 +1,41 @@
+package proc
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInterruptible_SendsInterrupt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := Interruptible(exec.CommandContext(ctx, "sh", "-c", `trap "exit 7" INT; sleep 5 & wait`))
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 7 {
+		t.Errorf("Wait() error = %v, want exit status 7 from the INT trap", err)
+	}
+}
+
+func TestRunForeground_ForwardsTerm(t *testing.T) {
+	cmd := exec.Command("sh", "-c", `trap "exit 9" TERM; sleep 5 & wait`)
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	}()
+
+	err := RunForeground(cmd)
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 9 {
+		t.Errorf("RunForeground() error = %v, want exit status 9 from the TERM trap", err)
+	}
+}
@@ -0,0 +1,227 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Diagnostic is a problem found in a config file.
+type Diagnostic struct {
+	Line    int // 1-based; 0 if unknown
+	Column  int // 1-based; 0 if unknown
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	switch {
+	case d.Line == 0:
+		return d.Message
+	case d.Column == 0:
+		return fmt.Sprintf("%d: %s", d.Line, d.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s", d.Line, d.Column, d.Message)
+}
+
+// ConfigPath returns the path of the config file of the current git
+// repository, whether or not it exists.
+func ConfigPath() (string, error) {
+	repoRoot, err := findGitRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(repoRoot, configFileName), nil
+}
+
+// CheckFile reads the config file at path and returns the problems found
+// in it: YAML syntax and type errors, and validation errors of the profiles
+// as merged with the built-in config, located in the file where possible.
+// The error is non-nil only if the file cannot be read.
+func CheckFile(path string) ([]Diagnostic, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	return Check(data), nil
+}
+
+// Check is CheckFile for the contents of a config file.
+func Check(data []byte) []Diagnostic {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return yamlDiagnostics(err)
+	}
+	userCfg, err := Parse(data)
+	if err != nil {
+		return parseDiagnostics(err)
+	}
+
+	root := documentRoot(&doc)
+	if root == nil || root.Kind != yaml.MappingNode {
+		// Empty file
+		return []Diagnostic{{Line: 1, Column: 1, Message: "no profiles defined"}}
+	}
+	profilesNode := mappingValue(root, "profiles")
+
+	var diags []Diagnostic
+	if len(userCfg.Profiles) == 0 {
+		diags = append(diags, diagAt(orNode(profilesNode, root), "no profiles defined"))
+	}
+
+	merged := MergeConfig(builtinConfig, *userCfg)
+	if merged.Default != "" {
+		if _, ok := merged.Profiles[merged.Default]; !ok {
+			node := orNode(mappingValue(root, "default"), root)
+			diags = append(diags, diagAt(node, fmt.Sprintf("default profile %q not found in profiles", merged.Default)))
+		}
+	}
+
+	for _, name := range merged.ProfileNames() {
+		if err := validateProfileName(name); err != nil {
+			node := orNode(mappingKey(profilesNode, name), orNode(profilesNode, root))
+			diags = append(diags, diagAt(node, err.Error()))
+		}
+		err := Validate(merged.Profiles[name])
+		if err == nil {
+			continue
+		}
+		node := orNode(mappingValue(profilesNode, name), orNode(profilesNode, root))
+		var fe *FieldError
+		if errors.As(err, &fe) {
+			node = lookupNode(node, fe.Field)
+		}
+		diags = append(diags, diagAt(node, fmt.Sprintf("profile %q: %v", name, err)))
+	}
+	if err := validateSharedHooks(&merged); err != nil {
+		node := root
+		var fe *FieldError
+		if errors.As(err, &fe) {
+			node = lookupNode(root, fe.Field)
+		}
+		diags = append(diags, diagAt(node, err.Error()))
+	}
+	return diags
+}
+
+// parseDiagnostics converts an error returned by Parse into diagnostics.
+func parseDiagnostics(err error) []Diagnostic {
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) {
+		return yamlDiagnostics(err)
+	}
+	var diags []Diagnostic
+	for _, e := range joined.Unwrap() {
+		var unknown *UnknownFieldError
+		if errors.As(e, &unknown) {
+			diags = append(diags, Diagnostic{Line: unknown.Line, Column: unknown.Column, Message: unknown.message()})
+			continue
+		}
+		diags = append(diags, yamlDiagnostics(e)...)
+	}
+	return diags
+}
+
+var yamlLineRe = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlDiagnostics converts a yaml.v3 decoding error, whose messages carry
+// the line number but no column, into diagnostics.
+func yamlDiagnostics(err error) []Diagnostic {
+	var msgs []string
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		msgs = typeErr.Errors
+	} else {
+		msg := err.Error()
+		msg = strings.TrimPrefix(msg, "parsing config: ")
+		msgs = []string{msg}
+	}
+
+	diags := make([]Diagnostic, 0, len(msgs))
+	for _, msg := range msgs {
+		d := Diagnostic{Message: msg}
+		if m := yamlLineRe.FindStringSubmatch(msg); m != nil {
+			d.Line, _ = strconv.Atoi(m[1])
+			d.Message = m[2]
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+func diagAt(node *yaml.Node, msg string) Diagnostic {
+	return Diagnostic{Line: node.Line, Column: node.Column, Message: msg}
+}
+
+func orNode(node, fallback *yaml.Node) *yaml.Node {
+	if node != nil {
+		return node
+	}
+	return fallback
+}
+
+// mappingValue returns the value node of key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// mappingKey returns the key node of key in a mapping node, or nil.
+func mappingKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// lookupNode follows a FieldError path such as "worktree.copy[1]" or
+// "hooks.pre-launch[0].timeout" from node. It returns the deepest node
+// found, so that a field that is missing in the file (or set by the
+// built-in config) is reported at its closest enclosing mapping.
+func lookupNode(node *yaml.Node, field string) *yaml.Node {
+	parts := strings.Split(field, ".")
+	for i, part := range parts {
+		key, index := part, -1
+		if j := strings.IndexByte(part, '['); j >= 0 && strings.HasSuffix(part, "]") {
+			n, err := strconv.Atoi(part[j+1 : len(part)-1])
+			if err == nil {
+				key, index = part[:j], n
+			}
+		}
+
+		next := mappingValue(node, key)
+		if next == nil {
+			return node
+		}
+		if index < 0 {
+			if i == len(parts)-1 && next.Kind != yaml.ScalarNode {
+				// Point at the key, e.g. for an unknown hook event
+				return mappingKey(node, key)
+			}
+			node = next
+			continue
+		}
+		if next.Kind != yaml.SequenceNode || index >= len(next.Content) {
+			return next
+		}
+		node = next.Content[index]
+	}
+	return node
+}
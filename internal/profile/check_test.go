@@ -0,0 +1,213 @@
+package profile
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	defer func(names []string) { ReservedNames = names }(ReservedNames)
+	ReservedNames = []string{"init"}
+
+	tests := []struct {
+		name string
+		yaml string
+		want []string // "line:col: message prefix"
+	}{
+		{
+			name: "valid",
+			yaml: `
+profiles:
+  dev:
+    environment: host
+    launch: shell
+`,
+		},
+		{
+			name: "profile named after a command",
+			yaml: `
+profiles:
+  init:
+    environment: host
+    launch: shell
+`,
+			want: []string{`3:3: profile name "init" is taken by the ` + "`aw init`" + ` command`},
+		},
+		{
+			name: "unknown launch mode",
+			yaml: `
+profiles:
+  dev:
+    environment: host
+    launch: shel
+`,
+			want: []string{`5:13: profile "dev": unknown launch mode`},
+		},
+		{
+			name: "missing field reported at the profile",
+			yaml: `
+profiles:
+  dev:
+    launch: shell
+`,
+			want: []string{`4:5: profile "dev": environment is required`},
+		},
+		{
+			name: "list item",
+			yaml: `
+profiles:
+  dev:
+    environment: host
+    launch: shell
+    worktree:
+      copy:
+        - .env
+        - ../secrets
+`,
+			want: []string{`9:11: profile "dev": worktree.copy: pattern "../secrets"`},
+		},
+		{
+			name: "hook field",
+			yaml: `
+profiles:
+  dev:
+    environment: host
+    launch: shell
+    hooks:
+      pre-launch:
+        - run: make
+          timeout: soon
+`,
+			want: []string{`9:20: profile "dev": hooks.pre-launch[0]: invalid timeout`},
+		},
+		{
+			name: "shared container hook with a host profile",
+			yaml: `
+hooks:
+  post-docker:
+    - run: npm ci
+      run-in: container
+profiles:
+  dev:
+    environment: host
+    launch: shell
+`,
+			want: []string{`5:15: hooks.post-docker[0]: run-in: container requires environment: docker in profile "dev"`},
+		},
+		{
+			name: "unknown hook event reported at its key",
+			yaml: `
+profiles:
+  dev:
+    environment: host
+    launch: shell
+    hooks:
+      pre-lunch: [make]
+`,
+			want: []string{`7:7: profile "dev": hooks: unknown event "pre-lunch"`},
+		},
+		{
+			name: "unknown default",
+			yaml: `
+default: nope
+profiles:
+  dev:
+    environment: host
+    launch: shell
+`,
+			want: []string{`2:10: default profile "nope" not found`},
+		},
+		{
+			name: "unknown fields",
+			yaml: `
+profiles:
+  dev:
+    environment: host
+    launch: shell
+    worktree:
+      on_create: make
+      brnach: x
+`,
+			want: []string{
+				`7:7: unknown field "on_create" in profiles.dev.worktree (did you mean "on-create"?)`,
+				`8:7: unknown field "brnach" in profiles.dev.worktree (did you mean "branch"?)`,
+			},
+		},
+		{
+			name: "type error",
+			yaml: `
+profiles:
+  dev:
+    environment: [host]
+`,
+			want: []string{`4: cannot unmarshal`},
+		},
+		{
+			name: "syntax error",
+			yaml: `
+profiles:
+  dev: launch: shell
+`,
+			want: []string{`3: mapping values are not allowed`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := Check([]byte(tt.yaml))
+			if len(diags) != len(tt.want) {
+				t.Fatalf("Check() = %v, want %d diagnostics", diags, len(tt.want))
+			}
+			for i, d := range diags {
+				if !strings.HasPrefix(d.String(), tt.want[i]) {
+					t.Errorf("diagnostic %d = %q, want prefix %q", i, d, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	data, err := json.Marshal(Schema())
+	if err != nil {
+		t.Fatalf("Schema() is not JSON-encodable: %v", err)
+	}
+
+	var schema struct {
+		Properties struct {
+			Hooks    json.RawMessage `json:"hooks"`
+			Profiles struct {
+				AdditionalProperties struct {
+					Required   []string                   `json:"required"`
+					Properties map[string]json.RawMessage `json:"properties"`
+				} `json:"additionalProperties"`
+			} `json:"profiles"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	p := schema.Properties.Profiles.AdditionalProperties
+	for _, field := range []string{"worktree", "environment", "launch", "zellij", "env", "dockerfile", "env-passthrough", "hooks"} {
+		if _, ok := p.Properties[field]; !ok {
+			t.Errorf("profile schema is missing %q", field)
+		}
+	}
+	if strings.Join(p.Required, ",") != "environment,launch" {
+		t.Errorf("required = %v", p.Required)
+	}
+	if !strings.Contains(string(p.Properties["launch"]), `"enum":["shell","claude","zellij","tmux"]`) {
+		t.Errorf("launch = %s, want an enum", p.Properties["launch"])
+	}
+	if !strings.Contains(string(p.Properties["worktree"]), `"on-create-in"`) {
+		t.Errorf("worktree = %s, want on-create-in", p.Properties["worktree"])
+	}
+	if !strings.Contains(string(p.Properties["hooks"]), `"pre-launch"`) {
+		t.Errorf("hooks = %s, want the hook events", p.Properties["hooks"])
+	}
+	if !strings.Contains(string(schema.Properties.Hooks), `"pre-launch"`) {
+		t.Errorf("top-level hooks = %s, want the hook events", schema.Properties.Hooks)
+	}
+}
@@ -0,0 +1,82 @@
+package profile
+
+import (
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Hook events, run before and after the pipeline stages they are named after.
+const (
+	HookPreWorktree  = "pre-worktree"
+	HookPostWorktree = "post-worktree"
+	HookPreDocker    = "pre-docker"
+	HookPostDocker   = "post-docker"
+	HookPreLaunch    = "pre-launch"
+	HookPostLaunch   = "post-launch"
+	HookOnError      = "on-error" // run when a stage or hook fails
+)
+
+// HookEvents lists all hook events in the order they can occur.
+var HookEvents = []string{
+	HookPreWorktree, HookPostWorktree,
+	HookPreDocker, HookPostDocker,
+	HookPreLaunch, HookPostLaunch,
+	HookOnError,
+}
+
+// Hooks maps hook events to the commands to run for them.
+type Hooks map[string][]HookCommand
+
+// CombineHooks returns the hooks to run for a profile: for each event, the
+// shared hooks of the config followed by the profile's own.
+func CombineHooks(shared, own Hooks) Hooks {
+	if len(shared) == 0 {
+		return own
+	}
+	combined := make(Hooks, len(shared)+len(own))
+	for _, event := range HookEvents {
+		if hooks := append(slices.Clip(shared[event]), own[event]...); len(hooks) > 0 {
+			combined[event] = hooks
+		}
+	}
+	return combined
+}
+
+// HookCommand is a single lifecycle hook command.
+// In YAML it can be given as a plain string, which sets only Run.
+type HookCommand struct {
+	Run             string            `yaml:"run"`                         // shell command, run via sh -c
+	Env             map[string]string `yaml:"env,omitempty"`               // extra env vars for the command
+	Timeout         string            `yaml:"timeout,omitempty"`           // e.g. "30s", "5m"; default: none
+	RunIn           HookRunIn         `yaml:"run-in,omitempty"`            // "host" or "container"; default: "host"
+	ContinueOnError bool              `yaml:"continue-on-error,omitempty"` // only warn if the command fails
+}
+
+// UnmarshalYAML accepts either a plain command string or a mapping.
+func (h *HookCommand) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*h = HookCommand{}
+		return value.Decode(&h.Run)
+	}
+	type plain HookCommand
+	return value.Decode((*plain)(h))
+}
+
+// EffectiveTimeout returns the parsed timeout, or 0 for no timeout.
+func (h HookCommand) EffectiveTimeout() time.Duration {
+	d, err := time.ParseDuration(h.Timeout)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// HookRunIn specifies where a hook command runs.
+type HookRunIn string
+
+const (
+	HookRunInHost      HookRunIn = "host"
+	HookRunInContainer HookRunIn = "container"
+)
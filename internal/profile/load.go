@@ -1,6 +1,8 @@
 package profile
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -38,6 +40,7 @@ func Load() (*Config, error) {
 		// Not in a git repo — use built-in default
 		cfg := builtinConfig
 		cfg.Source = ConfigSource{IsBuiltin: true}
+		cfg.Origins = builtinOrigins()
 		return &cfg, nil
 	}
 
@@ -53,6 +56,7 @@ func LoadFile(path string) (*Config, error) {
 		if os.IsNotExist(err) {
 			cfg := builtinConfig
 			cfg.Source = ConfigSource{IsBuiltin: true}
+			cfg.Origins = builtinOrigins()
 			return &cfg, nil
 		}
 		return nil, fmt.Errorf("reading config file: %w", err)
@@ -68,20 +72,65 @@ func LoadFile(path string) (*Config, error) {
 	return &merged, nil
 }
 
-// Parse parses YAML bytes into a Config.
+// Parse parses YAML bytes into a Config. Unknown fields are errors (see
+// UnknownFieldError), and configs of older versions are migrated to
+// CurrentVersion.
 func Parse(data []byte) (*Config, error) {
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
 		return nil, fmt.Errorf("parsing config: %w", err)
 	}
 
+	var cfg Config
+	if root := documentRoot(&doc); root != nil {
+		migrated, err := migrate(root, migrations)
+		if err != nil {
+			return nil, fmt.Errorf("parsing config: %w", err)
+		}
+		if errs := unknownFields(root); len(errs) > 0 {
+			joined := make([]error, len(errs))
+			for i, e := range errs {
+				joined[i] = e
+			}
+			return nil, fmt.Errorf("parsing config: %w", errors.Join(joined...))
+		}
+		if migrated {
+			// Decode the rewritten document; line numbers in errors no
+			// longer match the file.
+			if data, err = yaml.Marshal(root); err != nil {
+				return nil, fmt.Errorf("parsing config: %w", err)
+			}
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("parsing config: %w", err)
+		}
+	}
+
 	if cfg.Profiles == nil {
 		cfg.Profiles = make(map[string]Profile)
 	}
+	cfg.fieldKeys = profileFieldKeys(documentRoot(&doc))
 
 	return &cfg, nil
 }
 
+// documentRoot returns the top-level node of a decoded YAML document, or
+// nil if the document is empty.
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode {
+		if len(doc.Content) == 0 {
+			return nil
+		}
+		return doc.Content[0]
+	}
+	if doc.Kind == 0 {
+		return nil
+	}
+	return doc
+}
+
 // findGitRoot returns the top-level directory of the current git repository.
 var findGitRoot = func() (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
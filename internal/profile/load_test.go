@@ -127,8 +127,8 @@ profiles:
 	if p.Worktree == nil {
 		t.Fatal("Worktree should not be nil for empty object")
 	}
-	if p.Worktree.EffectiveBase() != "origin/main" {
-		t.Errorf("EffectiveBase() = %q, want %q", p.Worktree.EffectiveBase(), "origin/main")
+	if p.Worktree.Base != "" {
+		t.Errorf("Base = %q, want empty (the default base)", p.Worktree.Base)
 	}
 }
 
@@ -301,6 +301,82 @@ profiles:
 	}
 }
 
+func TestParse_EnvPassthrough(t *testing.T) {
+	yaml := `
+profiles:
+  test:
+    environment: docker
+    launch: claude
+    env-passthrough:
+      - ANTHROPIC_*
+      - HTTP_PROXY
+`
+	cfg, err := Parse([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	p := cfg.Profiles["test"]
+	if len(p.EnvPassthrough) != 2 || p.EnvPassthrough[0] != "ANTHROPIC_*" || p.EnvPassthrough[1] != "HTTP_PROXY" {
+		t.Errorf("EnvPassthrough = %v, want [ANTHROPIC_* HTTP_PROXY]", p.EnvPassthrough)
+	}
+}
+
+func TestParse_Hooks(t *testing.T) {
+	yaml := `
+profiles:
+  test:
+    environment: docker
+    launch: claude
+    hooks:
+      pre-docker:
+        - make assets
+      post-docker:
+        - run: npm ci
+          run-in: container
+          timeout: 5m
+          env:
+            CI: "1"
+          continue-on-error: true
+`
+	cfg, err := Parse([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	hooks := cfg.Profiles["test"].Hooks
+	if pre := hooks[HookPreDocker]; len(pre) != 1 || pre[0].Run != "make assets" {
+		t.Errorf("pre-docker = %+v, want [{Run: make assets}]", pre)
+	}
+	post := hooks[HookPostDocker]
+	if len(post) != 1 {
+		t.Fatalf("post-docker = %+v, want one hook", post)
+	}
+	h := post[0]
+	if h.Run != "npm ci" || h.RunIn != HookRunInContainer || h.Timeout != "5m" || h.Env["CI"] != "1" || !h.ContinueOnError {
+		t.Errorf("post-docker[0] = %+v", h)
+	}
+}
+
+func TestParse_SharedHooks(t *testing.T) {
+	yaml := `
+hooks:
+  pre-launch:
+    - docker compose up -d
+profiles:
+  test:
+    environment: host
+    launch: shell
+`
+	cfg, err := Parse([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if pre := cfg.Hooks[HookPreLaunch]; len(pre) != 1 || pre[0].Run != "docker compose up -d" {
+		t.Errorf("hooks.pre-launch = %+v", pre)
+	}
+}
+
 func TestLoad_NoGitRepo(t *testing.T) {
 	// Override findGitRoot to simulate not being in a git repo
 	orig := findGitRoot
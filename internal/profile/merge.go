@@ -30,6 +30,12 @@ func MergeProfile(base, override Profile) Profile {
 	if override.Dockerfile != "" {
 		merged.Dockerfile = override.Dockerfile
 	}
+	if override.EnvPassthrough != nil {
+		merged.EnvPassthrough = override.EnvPassthrough
+	}
+	if override.Hooks != nil {
+		merged.Hooks = override.Hooks
+	}
 
 	return merged
 }
@@ -38,31 +44,43 @@ func MergeProfile(base, override Profile) Profile {
 //   - Builtin-only profiles are preserved as-is.
 //   - User-only profiles are added as-is.
 //   - Profiles in both are merged (builtin base + user overlay).
-//   - User's Default takes precedence if non-empty.
+//   - User's Default, DefaultBase and Hooks take precedence if non-empty.
 func MergeConfig(builtin, user Config) Config {
 	merged := Config{
-		Default:  builtin.Default,
-		Profiles: make(map[string]Profile, len(builtin.Profiles)+len(user.Profiles)),
+		Default:     builtin.Default,
+		DefaultBase: builtin.DefaultBase,
+		Hooks:       builtin.Hooks,
+		Profiles:    make(map[string]Profile, len(builtin.Profiles)+len(user.Profiles)),
+		Origins:     make(map[string]ProfileOrigin, len(builtin.Profiles)+len(user.Profiles)),
 	}
 
 	// Start with all builtin profiles
 	for name, p := range builtin.Profiles {
 		merged.Profiles[name] = p
+		merged.Origins[name] = originOf(p, LayerBuiltin, builtin.fieldKeys[name])
 	}
 
 	// Overlay user profiles
 	for name, userProfile := range user.Profiles {
 		if base, ok := builtin.Profiles[name]; ok {
 			merged.Profiles[name] = MergeProfile(base, userProfile)
+			merged.Origins[name] = mergedOrigin(base, userProfile, user.fieldKeys[name])
 		} else {
 			merged.Profiles[name] = userProfile
+			merged.Origins[name] = originOf(userProfile, LayerUser, user.fieldKeys[name])
 		}
 	}
 
-	// User's Default takes precedence if non-empty
+	// User's Default, DefaultBase and Hooks take precedence if non-empty
 	if user.Default != "" {
 		merged.Default = user.Default
 	}
+	if user.DefaultBase != "" {
+		merged.DefaultBase = user.DefaultBase
+	}
+	if user.Hooks != nil {
+		merged.Hooks = user.Hooks
+	}
 
 	return merged
 }
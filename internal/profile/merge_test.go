@@ -1,6 +1,7 @@
 package profile
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -275,6 +276,61 @@ func TestMergeConfig_DefaultPreservedWhenUserEmpty(t *testing.T) {
 	}
 }
 
+func TestMergeConfig_DefaultBase(t *testing.T) {
+	builtin := Config{DefaultBase: "origin/main"}
+
+	if merged := MergeConfig(builtin, Config{}); merged.DefaultBase != "origin/main" {
+		t.Errorf("DefaultBase = %q, want %q (should be preserved from builtin)", merged.DefaultBase, "origin/main")
+	}
+	if merged := MergeConfig(builtin, Config{DefaultBase: "upstream/develop"}); merged.DefaultBase != "upstream/develop" {
+		t.Errorf("DefaultBase = %q, want %q", merged.DefaultBase, "upstream/develop")
+	}
+}
+
+func TestMergeConfig_Hooks(t *testing.T) {
+	builtin := Config{Hooks: Hooks{HookPreLaunch: {{Run: "builtin"}}}}
+
+	if merged := MergeConfig(builtin, Config{}); len(merged.Hooks[HookPreLaunch]) != 1 {
+		t.Errorf("Hooks = %v, want the builtin hooks", merged.Hooks)
+	}
+	user := Config{Hooks: Hooks{HookPostLaunch: {{Run: "user"}}}}
+	if merged := MergeConfig(builtin, user); len(merged.Hooks) != 1 || merged.Hooks[HookPostLaunch][0].Run != "user" {
+		t.Errorf("Hooks = %v, want the user's hooks only", merged.Hooks)
+	}
+}
+
+func TestCombineHooks(t *testing.T) {
+	shared := Hooks{
+		HookPreLaunch:  {{Run: "shared pre"}},
+		HookPostLaunch: {{Run: "shared post"}},
+	}
+	own := Hooks{
+		HookPreLaunch: {{Run: "own pre"}},
+		HookOnError:   {{Run: "own error"}},
+	}
+
+	got := CombineHooks(shared, own)
+	runs := func(event string) []string {
+		var r []string
+		for _, h := range got[event] {
+			r = append(r, h.Run)
+		}
+		return r
+	}
+	if r := runs(HookPreLaunch); !slices.Equal(r, []string{"shared pre", "own pre"}) {
+		t.Errorf("pre-launch = %q, want the shared hook first", r)
+	}
+	if r := runs(HookPostLaunch); !slices.Equal(r, []string{"shared post"}) {
+		t.Errorf("post-launch = %q", r)
+	}
+	if r := runs(HookOnError); !slices.Equal(r, []string{"own error"}) {
+		t.Errorf("on-error = %q", r)
+	}
+	if len(shared[HookPreLaunch]) != 1 {
+		t.Errorf("CombineHooks() modified the shared hooks: %v", shared)
+	}
+}
+
 func TestMergeProfile_EnvMerged(t *testing.T) {
 	base := Profile{
 		Environment: EnvironmentDocker,
@@ -362,6 +418,53 @@ func TestMergeProfile_EmptyDockerfileOverridePreservesBase(t *testing.T) {
 	}
 }
 
+func TestMergeProfile_OverrideEnvPassthrough(t *testing.T) {
+	base := Profile{
+		Environment:    EnvironmentDocker,
+		Launch:         LaunchClaude,
+		EnvPassthrough: []string{"ANTHROPIC_*"},
+	}
+	override := Profile{
+		EnvPassthrough: []string{"AWS_*"},
+	}
+
+	merged := MergeProfile(base, override)
+
+	if len(merged.EnvPassthrough) != 1 || merged.EnvPassthrough[0] != "AWS_*" {
+		t.Errorf("EnvPassthrough = %v, want [AWS_*]", merged.EnvPassthrough)
+	}
+
+	merged = MergeProfile(base, Profile{})
+	if len(merged.EnvPassthrough) != 1 || merged.EnvPassthrough[0] != "ANTHROPIC_*" {
+		t.Errorf("EnvPassthrough = %v, want [ANTHROPIC_*] (should be preserved from base)", merged.EnvPassthrough)
+	}
+}
+
+func TestMergeProfile_OverrideHooks(t *testing.T) {
+	base := Profile{
+		Environment: EnvironmentDocker,
+		Launch:      LaunchClaude,
+		Hooks:       Hooks{HookPreLaunch: {{Run: "base"}}},
+	}
+	override := Profile{
+		Hooks: Hooks{HookPostLaunch: {{Run: "override"}}},
+	}
+
+	merged := MergeProfile(base, override)
+
+	if _, ok := merged.Hooks[HookPreLaunch]; ok {
+		t.Errorf("Hooks = %v, want the override's hooks only", merged.Hooks)
+	}
+	if h := merged.Hooks[HookPostLaunch]; len(h) != 1 || h[0].Run != "override" {
+		t.Errorf("Hooks = %v, want post-launch override", merged.Hooks)
+	}
+
+	merged = MergeProfile(base, Profile{})
+	if h := merged.Hooks[HookPreLaunch]; len(h) != 1 || h[0].Run != "base" {
+		t.Errorf("Hooks = %v, want pre-launch base (should be preserved from base)", merged.Hooks)
+	}
+}
+
 func TestMergeConfig_WorktreeEmptyObjectEnablesWorktree(t *testing.T) {
 	builtin := Config{
 		Profiles: map[string]Profile{
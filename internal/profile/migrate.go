@@ -0,0 +1,49 @@
+package profile
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// migration rewrites a config document of one version into the next one.
+type migration func(root *yaml.Node) error
+
+// migrations upgrade older config formats: migrations[i] turns a version
+// i+1 document into a version i+2 one. To change the format incompatibly,
+// append a migration; CurrentVersion follows.
+var migrations = []migration{}
+
+// CurrentVersion is the config format version written by this aw. Configs
+// without a version field are taken to be of this version.
+var CurrentVersion = len(migrations) + 1
+
+// migrate upgrades root, a config document's top-level mapping, to the
+// version after the last of steps. It reports whether root was changed.
+func migrate(root *yaml.Node, steps []migration) (bool, error) {
+	latest := len(steps) + 1
+	versionNode := mappingValue(root, "version")
+	if versionNode == nil {
+		return false, nil
+	}
+
+	version, err := strconv.Atoi(versionNode.Value)
+	if err != nil || versionNode.Kind != yaml.ScalarNode || version < 1 {
+		return false, fmt.Errorf("line %d: invalid config version %q (must be a number from 1 to %d)", versionNode.Line, versionNode.Value, latest)
+	}
+	if version > latest {
+		return false, fmt.Errorf("line %d: config version %d is newer than this aw supports (%d); run aw update", versionNode.Line, version, latest)
+	}
+	if version == latest {
+		return false, nil
+	}
+
+	for v := version; v < latest; v++ {
+		if err := steps[v-1](root); err != nil {
+			return false, fmt.Errorf("migrating config from version %d to %d: %w", v, v+1, err)
+		}
+	}
+	versionNode.Value = strconv.Itoa(latest)
+	return true, nil
+}
@@ -0,0 +1,89 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrate(t *testing.T) {
+	// Version 2 renamed "launch: sh" to "launch: shell", version 3 added
+	// nothing that needs rewriting.
+	steps := []migration{
+		func(root *yaml.Node) error {
+			for _, p := range mappingValue(root, "profiles").Content {
+				if launch := mappingValue(p, "launch"); launch != nil && launch.Value == "sh" {
+					launch.Value = "shell"
+				}
+			}
+			return nil
+		},
+		func(root *yaml.Node) error { return nil },
+	}
+
+	tests := []struct {
+		name         string
+		yaml         string
+		wantMigrated bool
+		wantLaunch   string
+		wantErr      string
+	}{
+		{"no version is current", "profiles: {dev: {launch: sh}}", false, "sh", ""},
+		{"current version", "version: 3\nprofiles: {dev: {launch: sh}}", false, "sh", ""},
+		{"old version", "version: 1\nprofiles: {dev: {launch: sh}}", true, "shell", ""},
+		{"newer version", "version: 4\nprofiles: {}", false, "", "newer than this aw supports (3)"},
+		{"invalid version", "version: one\nprofiles: {}", false, "", "invalid config version"},
+		{"zero version", "version: 0\nprofiles: {}", false, "", "invalid config version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.yaml), &doc); err != nil {
+				t.Fatal(err)
+			}
+			root := documentRoot(&doc)
+
+			migrated, err := migrate(root, steps)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("migrate() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("migrate() error: %v", err)
+			}
+			if migrated != tt.wantMigrated {
+				t.Errorf("migrated = %v, want %v", migrated, tt.wantMigrated)
+			}
+
+			var cfg Config
+			if err := root.Decode(&cfg); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(cfg.Profiles["dev"].Launch); got != tt.wantLaunch {
+				t.Errorf("launch = %q, want %q", got, tt.wantLaunch)
+			}
+			if migrated && cfg.Version != 3 {
+				t.Errorf("version = %d, want 3", cfg.Version)
+			}
+		})
+	}
+}
+
+func TestParse_Version(t *testing.T) {
+	cfg, err := Parse([]byte("version: 1\nprofiles:\n  dev:\n    environment: host\n    launch: shell\n"))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if cfg.Version != 1 {
+		t.Errorf("Version = %d, want 1", cfg.Version)
+	}
+
+	_, err = Parse([]byte("version: 99\nprofiles: {}\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 1: config version 99 is newer") {
+		t.Errorf("Parse() error = %v, want a version error", err)
+	}
+}
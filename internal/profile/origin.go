@@ -0,0 +1,110 @@
+package profile
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Layer identifies a config layer.
+type Layer string
+
+const (
+	LayerBuiltin Layer = "builtin" // the built-in default config
+	LayerUser    Layer = "user"    // .agent-workspace.yml
+)
+
+// ProfileOrigin records where a merged profile and its fields came from.
+type ProfileOrigin struct {
+	Layers []Layer          // layers that define the profile, lowest first
+	Fields map[string]Layer // layer that set each top-level field, by YAML name
+}
+
+// Fields returns the top-level fields that are set in p, keyed by their
+// YAML name, with values as they would appear in YAML (e.g. for encoding
+// as JSON).
+func Fields(p Profile) (map[string]any, error) {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("encoding profile: %w", err)
+	}
+	var fields map[string]any
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("decoding profile: %w", err)
+	}
+	// environment and launch are always encoded
+	for k, v := range fields {
+		if v == nil || v == "" {
+			delete(fields, k)
+		}
+	}
+	return fields, nil
+}
+
+// originOf returns the origin of a profile defined in a single layer.
+// keys are the fields set in the config file (see profileFieldKeys), or nil
+// for a profile that was not read from one.
+func originOf(p Profile, layer Layer, keys []string) ProfileOrigin {
+	origin := ProfileOrigin{Layers: []Layer{layer}, Fields: make(map[string]Layer)}
+	for _, k := range setFields(p, keys) {
+		origin.Fields[k] = layer
+	}
+	return origin
+}
+
+// mergedOrigin returns the origin of MergeProfile(base, override), where
+// base comes from the builtin layer and override, with the fields keys (see
+// originOf), from the user layer.
+func mergedOrigin(base, override Profile, keys []string) ProfileOrigin {
+	origin := originOf(base, LayerBuiltin, nil)
+	origin.Layers = append(origin.Layers, LayerUser)
+	for _, k := range setFields(override, keys) {
+		origin.Fields[k] = LayerUser
+	}
+	return origin
+}
+
+// setFields returns the fields set in p: keys if it was read from a config
+// file, since an empty value such as `env: {}` is set there although
+// Fields omits it, and otherwise the fields Fields reports.
+func setFields(p Profile, keys []string) []string {
+	if keys != nil {
+		return keys
+	}
+	fields, _ := Fields(p)
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	return names
+}
+
+// profileFieldKeys returns the top-level keys of each profile in the root
+// node of a config file, except keys with a null value, which set nothing.
+func profileFieldKeys(root *yaml.Node) map[string][]string {
+	profiles := mappingValue(root, "profiles")
+	if profiles == nil || profiles.Kind != yaml.MappingNode {
+		return nil
+	}
+	keys := make(map[string][]string, len(profiles.Content)/2)
+	for i := 0; i+1 < len(profiles.Content); i += 2 {
+		p := profiles.Content[i+1]
+		names := []string{}
+		for j := 0; p.Kind == yaml.MappingNode && j+1 < len(p.Content); j += 2 {
+			if p.Content[j+1].Tag != "!!null" {
+				names = append(names, p.Content[j].Value)
+			}
+		}
+		keys[profiles.Content[i].Value] = names
+	}
+	return keys
+}
+
+// builtinOrigins returns the origins of the profiles of the builtin config.
+func builtinOrigins() map[string]ProfileOrigin {
+	origins := make(map[string]ProfileOrigin, len(builtinConfig.Profiles))
+	for name, p := range builtinConfig.Profiles {
+		origins[name] = originOf(p, LayerBuiltin, nil)
+	}
+	return origins
+}
@@ -0,0 +1,115 @@
+package profile
+
+import "testing"
+
+func TestFields(t *testing.T) {
+	p := Profile{
+		Worktree:    &WorktreeConfig{},
+		Environment: EnvironmentHost,
+		Env:         map[string]string{"A": "1"},
+		Hooks:       Hooks{HookPreLaunch: {{Run: "make"}}},
+	}
+
+	fields, err := Fields(p)
+	if err != nil {
+		t.Fatalf("Fields() error: %v", err)
+	}
+
+	for _, k := range []string{"worktree", "environment", "env", "hooks"} {
+		if _, ok := fields[k]; !ok {
+			t.Errorf("Fields() missing %q: %v", k, fields)
+		}
+	}
+	// launch is empty, zellij is nil
+	for _, k := range []string{"launch", "zellij"} {
+		if _, ok := fields[k]; ok {
+			t.Errorf("Fields() should not contain unset %q: %v", k, fields)
+		}
+	}
+}
+
+func TestMergeConfig_Origins(t *testing.T) {
+	builtin := Config{
+		Profiles: map[string]Profile{
+			"claude": {Environment: EnvironmentDocker, Launch: LaunchClaude},
+			"shared": {Environment: EnvironmentDocker, Launch: LaunchClaude, Dockerfile: "Dockerfile"},
+		},
+	}
+	user := Config{
+		Profiles: map[string]Profile{
+			"mine":   {Environment: EnvironmentHost, Launch: LaunchShell},
+			"shared": {Launch: LaunchShell},
+		},
+	}
+
+	merged := MergeConfig(builtin, user)
+
+	tests := []struct {
+		name   string
+		layers []Layer
+		fields map[string]Layer
+	}{
+		{"claude", []Layer{LayerBuiltin}, map[string]Layer{"environment": LayerBuiltin, "launch": LayerBuiltin}},
+		{"mine", []Layer{LayerUser}, map[string]Layer{"environment": LayerUser, "launch": LayerUser}},
+		{"shared", []Layer{LayerBuiltin, LayerUser}, map[string]Layer{"environment": LayerBuiltin, "launch": LayerUser, "dockerfile": LayerBuiltin}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origin := merged.Origins[tt.name]
+			if len(origin.Layers) != len(tt.layers) {
+				t.Fatalf("Layers = %v, want %v", origin.Layers, tt.layers)
+			}
+			for i := range tt.layers {
+				if origin.Layers[i] != tt.layers[i] {
+					t.Errorf("Layers = %v, want %v", origin.Layers, tt.layers)
+				}
+			}
+			if len(origin.Fields) != len(tt.fields) {
+				t.Errorf("Fields = %v, want %v", origin.Fields, tt.fields)
+			}
+			for k, v := range tt.fields {
+				if origin.Fields[k] != v {
+					t.Errorf("Fields[%s] = %q, want %q", k, origin.Fields[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeConfig_OriginsOfEmptyOverrides(t *testing.T) {
+	builtin := Config{
+		Profiles: map[string]Profile{
+			"shared": {Environment: EnvironmentDocker, Launch: LaunchClaude, Env: map[string]string{"A": "1"}, EnvPassthrough: []string{"TOKEN"}},
+		},
+	}
+	user, err := Parse([]byte(`
+profiles:
+  shared:
+    env: {}
+    env-passthrough: []
+    dockerfile:
+`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	origin := MergeConfig(builtin, *user).Origins["shared"]
+	want := map[string]Layer{"environment": LayerBuiltin, "launch": LayerBuiltin, "env": LayerUser, "env-passthrough": LayerUser}
+	if len(origin.Fields) != len(want) {
+		t.Errorf("Fields = %v, want %v", origin.Fields, want)
+	}
+	for k, v := range want {
+		if origin.Fields[k] != v {
+			t.Errorf("Fields[%s] = %q, want %q", k, origin.Fields[k], v)
+		}
+	}
+}
+
+func TestConfig_ProfileNames(t *testing.T) {
+	cfg := Config{Profiles: map[string]Profile{"b": {}, "c": {}, "a": {}}}
+	names := cfg.ProfileNames()
+	if len(names) != 3 || names[0] != "a" || names[1] != "b" || names[2] != "c" {
+		t.Errorf("ProfileNames() = %v, want [a b c]", names)
+	}
+}
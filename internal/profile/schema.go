@@ -0,0 +1,109 @@
+package profile
+
+import "reflect"
+
+// enumValues lists the allowed values of the string types used in profiles.
+var enumValues = map[reflect.Type][]string{
+	reflect.TypeOf(Environment("")):   {string(EnvironmentHost), string(EnvironmentDocker)},
+	reflect.TypeOf(LaunchMode("")):    {string(LaunchShell), string(LaunchClaude), string(LaunchZellij), string(LaunchTmux)},
+	reflect.TypeOf(FetchMode("")):     {string(FetchAlways), string(FetchIfMissing), string(FetchNever)},
+	reflect.TypeOf(SubmoduleMode("")): {string(SubmodulesNone), string(SubmodulesRecursive)},
+	reflect.TypeOf(HookRunIn("")):     {string(HookRunInHost), string(HookRunInContainer)},
+}
+
+// requiredFields lists the fields of a type that must be set.
+var requiredFields = map[reflect.Type][]string{
+	reflect.TypeOf(Profile{}):     {"environment", "launch"},
+	reflect.TypeOf(HookCommand{}): {"run"},
+}
+
+// Schema returns a JSON Schema describing .agent-workspace.yml, generated
+// from the Config and Profile types, for editor validation and completion.
+func Schema() map[string]any {
+	hookCommand := typeSchema(reflect.TypeOf(HookCommand{}))
+	hookEvents := make(map[string]any, len(HookEvents))
+	for _, event := range HookEvents {
+		hookEvents[event] = map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"anyOf": []any{map[string]any{"type": "string"}, hookCommand},
+			},
+		}
+	}
+
+	hooks := map[string]any{
+		"type":                 "object",
+		"properties":           hookEvents,
+		"additionalProperties": false,
+	}
+	profile := typeSchema(reflect.TypeOf(Profile{}))
+	profile["properties"].(map[string]any)["hooks"] = hooks
+
+	return map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   configFileName,
+		"type":    "object",
+		"properties": map[string]any{
+			"version": map[string]any{
+				"type":        "integer",
+				"minimum":     1,
+				"maximum":     CurrentVersion,
+				"description": "config format version",
+			},
+			"default": map[string]any{
+				"type":        "string",
+				"description": "profile used when none is given on the command line",
+			},
+			"default-base": map[string]any{
+				"type":        "string",
+				"description": "worktree base for profiles that set none; default: the remote's default branch",
+			},
+			"hooks": hooks,
+			"profiles": map[string]any{
+				"type":                 "object",
+				"additionalProperties": profile,
+			},
+		},
+		"required":             []string{"profiles"},
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema returns the JSON Schema of a type, using the yaml tags of
+// struct fields as property names.
+func typeSchema(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if values, ok := enumValues[t]; ok {
+		return map[string]any{"type": "string", "enum": values}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		props := make(map[string]any)
+		for name, f := range yamlFields(t) {
+			props[name] = typeSchema(f.Type)
+		}
+		s := map[string]any{
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+		if req, ok := requiredFields[t]; ok {
+			s["required"] = req
+		}
+		return s
+	}
+	return map[string]any{}
+}
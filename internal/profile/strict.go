@@ -0,0 +1,172 @@
+package profile
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownFieldError reports a key in the config file that does not
+// correspond to any config field, usually a typo such as "on_create".
+type UnknownFieldError struct {
+	Line       int
+	Column     int
+	Path       string // enclosing field, e.g. "profiles.dev.worktree"; empty at the top level
+	Key        string
+	Suggestion string // closest known key, if any
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.message())
+}
+
+// message returns the error message without the line number.
+func (e *UnknownFieldError) message() string {
+	msg := fmt.Sprintf("unknown field %q", e.Key)
+	if e.Path != "" {
+		msg += " in " + e.Path
+	}
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", e.Suggestion)
+	}
+	return msg
+}
+
+// unknownFields returns the keys of node, a decoded config document, that
+// have no matching field in Config, in document order.
+func unknownFields(node *yaml.Node) []*UnknownFieldError {
+	var errs []*UnknownFieldError
+	walkFields(node, reflect.TypeOf(Config{}), "", &errs)
+	return errs
+}
+
+func walkFields(node *yaml.Node, t reflect.Type, path string, errs *[]*UnknownFieldError) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return // scalar shorthand (HookCommand) or a type error
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			f, ok := fields[key.Value]
+			if !ok {
+				*errs = append(*errs, &UnknownFieldError{
+					Line:       key.Line,
+					Column:     key.Column,
+					Path:       path,
+					Key:        key.Value,
+					Suggestion: suggest(key.Value, fieldNames(t)),
+				})
+				continue
+			}
+			walkFields(value, f.Type, joinPath(path, key.Value), errs)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkFields(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), errs)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			walkFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// yamlFields maps the YAML names of the fields of struct type t to the
+// fields.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if name, ok := yamlName(f); ok {
+			fields[name] = f
+		}
+	}
+	return fields
+}
+
+// fieldNames returns the YAML names of the fields of struct type t in
+// declaration order.
+func fieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := yamlName(t.Field(i)); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// yamlName returns the YAML name of a struct field, and false if the field
+// is not decoded from YAML.
+func yamlName(f reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "-" || !f.IsExported() {
+		return "", false
+	}
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+	return name, true
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// suggest returns the candidate closest to s, or "" if none is close
+// enough to be a likely typo. Case and "_" vs "-" are ignored.
+func suggest(s string, candidates []string) string {
+	norm := func(s string) string { return strings.ReplaceAll(strings.ToLower(s), "_", "-") }
+	best, bestDist := "", -1
+	for _, c := range candidates {
+		d := levenshtein(norm(s), norm(c))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	if bestDist < 0 || bestDist > max(2, len(s)/3) {
+		return ""
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,123 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_UnknownFields(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{
+			name: "top level",
+			yaml: `
+defualt: dev
+profiles: {}
+`,
+			want: []string{`line 2: unknown field "defualt" (did you mean "default"?)`},
+		},
+		{
+			name: "snake case",
+			yaml: `
+profiles:
+  dev:
+    environment: host
+    launch: shell
+    worktree:
+      on_create: make setup
+`,
+			want: []string{`line 7: unknown field "on_create" in profiles.dev.worktree (did you mean "on-create"?)`},
+		},
+		{
+			name: "camel case",
+			yaml: `
+profiles:
+  dev:
+    environment: docker
+    launch: shell
+    dockerFile: ./Dockerfile
+`,
+			want: []string{`line 6: unknown field "dockerFile" in profiles.dev (did you mean "dockerfile"?)`},
+		},
+		{
+			name: "hook command",
+			yaml: `
+profiles:
+  dev:
+    environment: host
+    launch: shell
+    hooks:
+      pre-launch:
+        - make
+        - run: make lint
+          timout: 1m
+`,
+			want: []string{`line 10: unknown field "timout" in profiles.dev.hooks.pre-launch[1] (did you mean "timeout"?)`},
+		},
+		{
+			name: "no close match",
+			yaml: `
+profiles:
+  dev:
+    environment: host
+    launch: shell
+    kubernetes: true
+`,
+			want: []string{`line 6: unknown field "kubernetes" in profiles.dev`},
+		},
+		{
+			name: "all reported",
+			yaml: `
+profiles:
+  dev:
+    enviroment: host
+    lanch: shell
+`,
+			want: []string{
+				`line 4: unknown field "enviroment" in profiles.dev (did you mean "environment"?)`,
+				`line 5: unknown field "lanch" in profiles.dev (did you mean "launch"?)`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml))
+			if err == nil {
+				t.Fatal("Parse() should return an error for unknown fields")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error = %q, want it to contain %q", err, want)
+				}
+			}
+			if strings.Count(err.Error(), "unknown field") != len(tt.want) {
+				t.Errorf("error = %q, want %d unknown fields", err, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"base", "fetch", "branch", "branch-prefix", "on-create", "on-create-in"}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"branch_prefix", "branch-prefix"},
+		{"OnCreate", "on-create"},
+		{"on-creat", "on-create"},
+		{"brnach", "branch"},
+		{"fetch-mode", ""},
+		{"x", ""},
+		{"submodules", ""},
+	}
+	for _, tt := range tests {
+		if got := suggest(tt.in, candidates); got != tt.want {
+			t.Errorf("suggest(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package profile
 
+import "sort"
+
 // ConfigSource describes where the config was loaded from.
 type ConfigSource struct {
 	IsBuiltin bool   // true if the built-in default config was used
@@ -8,36 +10,110 @@ type ConfigSource struct {
 
 // Config represents the top-level .agent-workspace.yml file.
 type Config struct {
-	Default  string             `yaml:"default"`
-	Profiles map[string]Profile `yaml:"profiles"`
-	Source   ConfigSource       `yaml:"-"`
+	Version     int                      `yaml:"version,omitempty"` // config format version; default: CurrentVersion
+	Default     string                   `yaml:"default"`
+	DefaultBase string                   `yaml:"default-base,omitempty"` // worktree base for profiles that set none; default: the remote's default branch
+	Hooks       Hooks                    `yaml:"hooks,omitempty"`        // lifecycle hooks of every profile, run before the profile's own
+	Profiles    map[string]Profile       `yaml:"profiles"`
+	Source      ConfigSource             `yaml:"-"`
+	Origins     map[string]ProfileOrigin `yaml:"-"` // set by Load: where each profile came from
+
+	// fieldKeys holds the top-level keys of each profile as written in the
+	// config file, set by Parse for the origins of the fields.
+	fieldKeys map[string][]string
+}
+
+// ProfileNames returns the names of the profiles in sorted order.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // Profile describes a single named workspace profile.
 type Profile struct {
-	Worktree    *WorktreeConfig   `yaml:"worktree,omitempty"`
-	Environment Environment       `yaml:"environment"`
-	Launch      LaunchMode        `yaml:"launch"`
-	Zellij      *ZellijConfig     `yaml:"zellij,omitempty"`
-	Env         map[string]string `yaml:"env,omitempty"`    // custom env vars to pass into Docker container
-	Dockerfile  string            `yaml:"dockerfile,omitempty"` // custom Dockerfile path (docker environment only)
+	Worktree       *WorktreeConfig   `yaml:"worktree,omitempty"`
+	Environment    Environment       `yaml:"environment"`
+	Launch         LaunchMode        `yaml:"launch"`
+	Zellij         *ZellijConfig     `yaml:"zellij,omitempty"`
+	Env            map[string]string `yaml:"env,omitempty"`             // custom env vars to pass into the workspace
+	Dockerfile     string            `yaml:"dockerfile,omitempty"`      // custom Dockerfile path (docker environment only)
+	EnvPassthrough []string          `yaml:"env-passthrough,omitempty"` // host env var names or glob patterns (e.g. "ANTHROPIC_*") to forward
+	Hooks          Hooks             `yaml:"hooks,omitempty"`           // lifecycle hook commands by event
 }
 
 // WorktreeConfig controls git worktree creation.
 type WorktreeConfig struct {
-	Base     string `yaml:"base,omitempty"`      // default: "origin/main"
-	OnCreate string `yaml:"on-create,omitempty"` // shell command to run after worktree creation
-	OnEnd    string `yaml:"on-end,omitempty"`    // shell command to run after launched process exits
+	Base         string        `yaml:"base,omitempty"`          // default: Config.DefaultBase, or the remote's default branch
+	Fetch        FetchMode     `yaml:"fetch,omitempty"`         // when to fetch a remote base; default: "always"
+	Dir          string        `yaml:"dir,omitempty"`           // where worktrees are created; default: "worktrees" (relative to repo root)
+	Branch       string        `yaml:"branch,omitempty"`        // branch name template; default: "{{words}}"
+	BranchPrefix string        `yaml:"branch-prefix,omitempty"` // prepended to generated branch names (e.g. "aw/")
+	Sparse       []string      `yaml:"sparse,omitempty"`        // directories to check out (cone-mode sparse checkout); default: everything
+	Submodules   SubmoduleMode `yaml:"submodules,omitempty"`    // "recursive" or "none"; default: "none"
+	LFS          bool          `yaml:"lfs,omitempty"`           // fetch and check out Git LFS files
+	Copy         []string      `yaml:"copy,omitempty"`          // files to copy from the main checkout (glob patterns, relative to repo root)
+	Link         []string      `yaml:"link,omitempty"`          // files to symlink from the main checkout (glob patterns, relative to repo root)
+	OnCreate     string        `yaml:"on-create,omitempty"`     // shell command to run after worktree creation
+	OnCreateIn   HookRunIn     `yaml:"on-create-in,omitempty"`  // where on-create runs: "host" or "container"; default: "host"
+	OnEnd        string        `yaml:"on-end,omitempty"`        // shell command to run after launched process exits
 }
 
-// EffectiveBase returns the base ref, defaulting to "origin/main" if empty.
-func (w *WorktreeConfig) EffectiveBase() string {
+// EffectiveBase returns the base ref, defaulting to defaultBase (the
+// config's default-base, or the detected default branch) if empty.
+func (w *WorktreeConfig) EffectiveBase(defaultBase string) string {
 	if w.Base != "" {
 		return w.Base
 	}
-	return "origin/main"
+	return defaultBase
+}
+
+// EffectiveFetch returns the fetch mode, defaulting to "always" if empty.
+func (w *WorktreeConfig) EffectiveFetch() FetchMode {
+	if w.Fetch != "" {
+		return w.Fetch
+	}
+	return FetchAlways
+}
+
+// EffectiveDir returns the worktree directory setting, defaulting to
+// "worktrees" (relative to the repository root) if empty.
+func (w *WorktreeConfig) EffectiveDir() string {
+	if w.Dir != "" {
+		return w.Dir
+	}
+	return "worktrees"
+}
+
+// EffectiveBranch returns the branch name template, defaulting to
+// "{{words}}" (three random words) if empty.
+func (w *WorktreeConfig) EffectiveBranch() string {
+	if w.Branch != "" {
+		return w.Branch
+	}
+	return "{{words}}"
 }
 
+// FetchMode specifies when the base ref of a worktree is fetched.
+type FetchMode string
+
+const (
+	FetchAlways    FetchMode = "always"     // fetch before every worktree creation
+	FetchIfMissing FetchMode = "if-missing" // fetch only if the base has never been fetched
+	FetchNever     FetchMode = "never"      // never fetch; use the last fetched ref
+)
+
+// SubmoduleMode specifies how submodules are set up in a new worktree.
+type SubmoduleMode string
+
+const (
+	SubmodulesNone      SubmoduleMode = "none"      // leave submodules uninitialized
+	SubmodulesRecursive SubmoduleMode = "recursive" // initialize and update submodules recursively
+)
+
 // ZellijConfig controls zellij session settings.
 type ZellijConfig struct {
 	Layout string `yaml:"layout,omitempty"` // "default" or custom path (future)
@@ -58,4 +134,36 @@ const (
 	LaunchShell  LaunchMode = "shell"
 	LaunchClaude LaunchMode = "claude"
 	LaunchZellij LaunchMode = "zellij"
+	LaunchTmux   LaunchMode = "tmux"
 )
+
+// Multiplexer returns the terminal multiplexer the launch mode runs, or ""
+// if it runs none.
+func (m LaunchMode) Multiplexer() string {
+	switch m {
+	case LaunchZellij, LaunchTmux:
+		return string(m)
+	}
+	return ""
+}
+
+// WithDefaults returns a copy of p with the defaults of unset worktree
+// fields filled in, as they are used when the profile runs. defaultBase is
+// the base used when the profile sets none.
+func WithDefaults(p Profile, defaultBase string) Profile {
+	if p.Worktree != nil {
+		w := *p.Worktree
+		w.Base = w.EffectiveBase(defaultBase)
+		w.Fetch = w.EffectiveFetch()
+		w.Dir = w.EffectiveDir()
+		w.Branch = w.EffectiveBranch()
+		if w.Submodules == "" {
+			w.Submodules = SubmodulesNone
+		}
+		if w.OnCreate != "" && w.OnCreateIn == "" {
+			w.OnCreateIn = HookRunInHost
+		}
+		p.Worktree = &w
+	}
+	return p
+}
@@ -8,7 +8,7 @@ func TestWorktreeConfig_EffectiveBase(t *testing.T) {
 		base string
 		want string
 	}{
-		{"empty defaults to origin/main", "", "origin/main"},
+		{"empty uses the default base", "", "upstream/master"},
 		{"custom base", "origin/develop", "origin/develop"},
 		{"specific commit", "abc123", "abc123"},
 	}
@@ -16,9 +16,111 @@ func TestWorktreeConfig_EffectiveBase(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			w := &WorktreeConfig{Base: tt.base}
-			if got := w.EffectiveBase(); got != tt.want {
+			if got := w.EffectiveBase("upstream/master"); got != tt.want {
 				t.Errorf("EffectiveBase() = %q, want %q", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestWorktreeConfig_EffectiveDir(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  string
+		want string
+	}{
+		{"empty defaults to worktrees", "", "worktrees"},
+		{"custom dir", "~/worktrees/{{repo}}/{{name}}", "~/worktrees/{{repo}}/{{name}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &WorktreeConfig{Dir: tt.dir}
+			if got := w.EffectiveDir(); got != tt.want {
+				t.Errorf("EffectiveDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorktreeConfig_EffectiveBranch(t *testing.T) {
+	tests := []struct {
+		name   string
+		branch string
+		want   string
+	}{
+		{"empty defaults to random words", "", "{{words}}"},
+		{"custom template", "{{user}}/{{date}}-{{words}}", "{{user}}/{{date}}-{{words}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &WorktreeConfig{Branch: tt.branch}
+			if got := w.EffectiveBranch(); got != tt.want {
+				t.Errorf("EffectiveBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorktreeConfig_EffectiveFetch(t *testing.T) {
+	tests := []struct {
+		name  string
+		fetch FetchMode
+		want  FetchMode
+	}{
+		{"empty defaults to always", "", FetchAlways},
+		{"if-missing", FetchIfMissing, FetchIfMissing},
+		{"never", FetchNever, FetchNever},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &WorktreeConfig{Fetch: tt.fetch}
+			if got := w.EffectiveFetch(); got != tt.want {
+				t.Errorf("EffectiveFetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	p := Profile{
+		Worktree:    &WorktreeConfig{Base: "origin/develop", OnCreate: "make setup"},
+		Environment: EnvironmentHost,
+		Launch:      LaunchShell,
+	}
+	got := WithDefaults(p, "origin/main")
+
+	w := got.Worktree
+	if w.Base != "origin/develop" || w.Fetch != FetchAlways || w.Dir != "worktrees" || w.Branch != "{{words}}" {
+		t.Errorf("worktree = %+v", w)
+	}
+	if w.Submodules != SubmodulesNone || w.OnCreateIn != HookRunInHost {
+		t.Errorf("submodules = %q, on-create-in = %q", w.Submodules, w.OnCreateIn)
+	}
+	if p.Worktree.Fetch != "" {
+		t.Error("WithDefaults modified its argument")
+	}
+
+	if got := WithDefaults(Profile{Worktree: &WorktreeConfig{}}, "origin/trunk"); got.Worktree.Base != "origin/trunk" {
+		t.Errorf("base = %q, want the default base", got.Worktree.Base)
+	}
+
+	if got := WithDefaults(Profile{Environment: EnvironmentHost}, "origin/main"); got.Worktree != nil {
+		t.Errorf("worktree = %+v, want nil", got.Worktree)
+	}
+}
+
+func TestLaunchMode_Multiplexer(t *testing.T) {
+	for mode, want := range map[LaunchMode]string{
+		LaunchZellij: "zellij",
+		LaunchTmux:   "tmux",
+		LaunchClaude: "",
+		LaunchShell:  "",
+	} {
+		if got := mode.Multiplexer(); got != want {
+			t.Errorf("%q.Multiplexer() = %q, want %q", mode, got, want)
+		}
+	}
+}
@@ -1,42 +1,227 @@
 package profile
 
 import (
+	"errors"
 	"fmt"
+	"path"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 )
 
+// FieldError is a validation error for a single field of a profile.
+type FieldError struct {
+	Field string // YAML path within the profile, e.g. "worktree.copy[1]"
+	Msg   string
+}
+
+func (e *FieldError) Error() string { return e.Msg }
+
+func fieldErrorf(field, format string, args ...any) error {
+	return &FieldError{Field: field, Msg: fmt.Sprintf(format, args...)}
+}
+
 // Validate checks that a profile configuration is semantically valid.
+// Errors about a specific field are *FieldError.
 func Validate(p Profile) error {
 	// Validate environment
 	switch p.Environment {
 	case EnvironmentHost, EnvironmentDocker:
 		// ok
 	case "":
-		return fmt.Errorf("environment is required (\"host\" or \"docker\")")
+		return fieldErrorf("environment", "environment is required (\"host\" or \"docker\")")
 	default:
-		return fmt.Errorf("unknown environment: %q (must be \"host\" or \"docker\")", p.Environment)
+		return fieldErrorf("environment", "unknown environment: %q (must be \"host\" or \"docker\")", p.Environment)
 	}
 
 	// Validate launch mode
 	switch p.Launch {
-	case LaunchShell, LaunchClaude, LaunchZellij:
+	case LaunchShell, LaunchClaude, LaunchZellij, LaunchTmux:
 		// ok
 	case "":
-		return fmt.Errorf("launch is required (\"shell\", \"claude\", or \"zellij\")")
+		return fieldErrorf("launch", "launch is required (\"shell\", \"claude\", \"zellij\", or \"tmux\")")
 	default:
-		return fmt.Errorf("unknown launch mode: %q (must be \"shell\", \"claude\", or \"zellij\")", p.Launch)
+		return fieldErrorf("launch", "unknown launch mode: %q (must be \"shell\", \"claude\", \"zellij\", or \"tmux\")", p.Launch)
 	}
 
 	// Validate zellij config is only used with launch: zellij
 	if p.Zellij != nil && p.Launch != LaunchZellij {
-		return fmt.Errorf("zellij config is only valid with launch: zellij")
+		return fieldErrorf("zellij", "zellij config is only valid with launch: zellij")
 	}
 
 	// Validate dockerfile is only used with environment: docker
 	if p.Dockerfile != "" && p.Environment != EnvironmentDocker {
-		return fmt.Errorf("dockerfile is only valid with environment: docker")
+		return fieldErrorf("dockerfile", "dockerfile is only valid with environment: docker")
+	}
+
+	// Validate worktree settings
+	if p.Worktree != nil {
+		switch p.Worktree.Fetch {
+		case "", FetchAlways, FetchIfMissing, FetchNever:
+			// ok
+		default:
+			return fieldErrorf("worktree.fetch", "unknown worktree fetch mode: %q (must be \"always\", \"if-missing\", or \"never\")", p.Worktree.Fetch)
+		}
+		switch p.Worktree.Submodules {
+		case "", SubmodulesNone, SubmodulesRecursive:
+			// ok
+		default:
+			return fieldErrorf("worktree.submodules", "unknown worktree submodules mode: %q (must be \"recursive\" or \"none\")", p.Worktree.Submodules)
+		}
+		switch p.Worktree.OnCreateIn {
+		case "", HookRunInHost:
+			// ok
+		case HookRunInContainer:
+			if p.Environment != EnvironmentDocker {
+				return fieldErrorf("worktree.on-create-in", "worktree.on-create-in: container requires environment: docker")
+			}
+		default:
+			return fieldErrorf("worktree.on-create-in", "unknown worktree on-create-in: %q (must be \"host\" or \"container\")", p.Worktree.OnCreateIn)
+		}
+		for i, dir := range p.Worktree.Sparse {
+			field := fmt.Sprintf("worktree.sparse[%d]", i)
+			if dir == "" || strings.ContainsAny(dir, "*?[\\") {
+				return fieldErrorf(field, "worktree.sparse: %q must be a directory path (patterns are not supported)", dir)
+			}
+			if path.IsAbs(dir) || strings.HasPrefix(path.Clean(dir)+"/", "../") {
+				return fieldErrorf(field, "worktree.sparse: %q must be relative to the repository root", dir)
+			}
+		}
+		if err := validateRepoPatterns("worktree.copy", p.Worktree.Copy, p.Worktree.EffectiveDir()); err != nil {
+			return err
+		}
+		if err := validateRepoPatterns("worktree.link", p.Worktree.Link, p.Worktree.EffectiveDir()); err != nil {
+			return err
+		}
 	}
 
+	// Validate env-passthrough patterns
+	for i, pattern := range p.EnvPassthrough {
+		field := fmt.Sprintf("env-passthrough[%d]", i)
+		if pattern == "" {
+			return fieldErrorf(field, "env-passthrough: empty pattern")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fieldErrorf(field, "env-passthrough: invalid pattern %q", pattern)
+		}
+	}
+
+	return validateHooks(p.Hooks, p.Environment)
+}
+
+// validateHooks checks a hooks block, of a profile or the config, for
+// profiles with environment env.
+func validateHooks(hooks Hooks, env Environment) error {
+	events := make([]string, 0, len(hooks))
+	for event := range hooks {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	for _, event := range events {
+		if !slices.Contains(HookEvents, event) {
+			return fieldErrorf("hooks."+event, "hooks: unknown event %q (must be one of %s)", event, strings.Join(HookEvents, ", "))
+		}
+		for i, h := range hooks[event] {
+			where := fmt.Sprintf("hooks.%s[%d]", event, i)
+			if strings.TrimSpace(h.Run) == "" {
+				return fieldErrorf(where, "%s: run is required", where)
+			}
+			if h.Timeout != "" {
+				if d, err := time.ParseDuration(h.Timeout); err != nil || d <= 0 {
+					return fieldErrorf(where+".timeout", "%s: invalid timeout %q (e.g. \"30s\" or \"5m\")", where, h.Timeout)
+				}
+			}
+			switch h.RunIn {
+			case "", HookRunInHost:
+				// ok
+			case HookRunInContainer:
+				if env != EnvironmentDocker {
+					return fieldErrorf(where+".run-in", "%s: run-in: container requires environment: docker", where)
+				}
+				if event == HookPreWorktree || event == HookPostWorktree || event == HookPreDocker {
+					return fieldErrorf(where+".run-in", "%s: run-in: container is not available before the docker stage", where)
+				}
+			default:
+				return fieldErrorf(where+".run-in", "%s: unknown run-in: %q (must be \"host\" or \"container\")", where, h.RunIn)
+			}
+		}
+	}
+	return nil
+}
+
+// validateSharedHooks checks the top-level hooks block, whose hooks run in
+// every profile: run-in: container requires all of them to use docker.
+func validateSharedHooks(cfg *Config) error {
+	if err := validateHooks(cfg.Hooks, EnvironmentDocker); err != nil {
+		return err
+	}
+	for _, name := range cfg.ProfileNames() {
+		var fe *FieldError
+		if err := validateHooks(cfg.Hooks, cfg.Profiles[name].Environment); errors.As(err, &fe) {
+			return fieldErrorf(fe.Field, "%v in profile %q (move the hook into the profiles that use docker)", err, name)
+		}
+	}
+	return nil
+}
+
+// validateRepoPatterns checks glob patterns that must stay within the
+// repository root and must not match worktreeDir (the worktree.dir setting)
+// or a directory containing it, which would be copied into itself.
+func validateRepoPatterns(field string, patterns []string, worktreeDir string) error {
+	for i, pattern := range patterns {
+		item := fmt.Sprintf("%s[%d]", field, i)
+		if pattern == "" {
+			return fieldErrorf(item, "%s: empty pattern", field)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fieldErrorf(item, "%s: invalid pattern %q", field, pattern)
+		}
+		if path.IsAbs(pattern) || pattern == ".." || strings.HasPrefix(path.Clean(pattern), "../") {
+			return fieldErrorf(item, "%s: pattern %q must be relative to the repository root", field, pattern)
+		}
+		for _, dir := range repoDirPrefixes(worktreeDir) {
+			if ok, _ := path.Match(path.Clean(pattern), dir); ok {
+				return fieldErrorf(item, "%s: pattern %q matches the worktree directory %q", field, pattern, worktreeDir)
+			}
+		}
+	}
+	return nil
+}
+
+// repoDirPrefixes returns the directories inside the repository that lead to
+// the worktree.dir setting dir, e.g. "a" and "a/b" for "a/b/{{name}}". It
+// returns nothing if dir is outside the repository.
+func repoDirPrefixes(dir string) []string {
+	if path.IsAbs(dir) || strings.HasPrefix(dir, "~") {
+		return nil
+	}
+	dir = path.Clean(dir)
+	if dir == "." || dir == ".." || strings.HasPrefix(dir, "../") {
+		return nil
+	}
+	var prefixes []string
+	parts := strings.Split(dir, "/")
+	for i, part := range parts {
+		if strings.Contains(part, "{{") {
+			break
+		}
+		prefixes = append(prefixes, strings.Join(parts[:i+1], "/"))
+	}
+	return prefixes
+}
+
+// ReservedNames are the names aw takes as commands rather than profiles,
+// such as "init". The cmd package sets them.
+var ReservedNames []string
+
+// validateProfileName checks that name can be given to aw as a profile,
+// i.e. that no command shadows it.
+func validateProfileName(name string) error {
+	if slices.Contains(ReservedNames, name) {
+		return fmt.Errorf("profile name %q is taken by the `aw %s` command (rename the profile)", name, name)
+	}
 	return nil
 }
 
@@ -55,11 +240,17 @@ func ValidateConfig(cfg *Config) error {
 
 	// Validate each profile
 	var errs []string
-	for name, p := range cfg.Profiles {
-		if err := Validate(p); err != nil {
+	for _, name := range cfg.ProfileNames() {
+		if err := validateProfileName(name); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if err := Validate(cfg.Profiles[name]); err != nil {
 			errs = append(errs, fmt.Sprintf("profile %q: %v", name, err))
 		}
 	}
+	if err := validateSharedHooks(cfg); err != nil {
+		errs = append(errs, err.Error())
+	}
 
 	if len(errs) > 0 {
 		return fmt.Errorf("config validation errors:\n  %s", strings.Join(errs, "\n  "))
@@ -35,6 +35,14 @@ func TestValidate(t *testing.T) {
 				Zellij:      &ZellijConfig{Layout: "default"},
 			},
 		},
+		{
+			name: "valid worktree + host + tmux",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{},
+				Environment: EnvironmentHost,
+				Launch:      LaunchTmux,
+			},
+		},
 		{
 			name: "missing environment",
 			profile: Profile{
@@ -61,7 +69,7 @@ func TestValidate(t *testing.T) {
 			name: "unknown launch mode",
 			profile: Profile{
 				Environment: EnvironmentHost,
-				Launch:      "tmux",
+				Launch:      "screen",
 			},
 			wantErr: "unknown launch mode",
 		},
@@ -91,6 +99,248 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: "dockerfile is only valid with environment: docker",
 		},
+		{
+			name: "valid env-passthrough patterns",
+			profile: Profile{
+				Environment:    EnvironmentDocker,
+				Launch:         LaunchClaude,
+				EnvPassthrough: []string{"ANTHROPIC_*", "HTTP_PROXY", "AWS_[A-Z]*"},
+			},
+		},
+		{
+			name: "invalid env-passthrough pattern",
+			profile: Profile{
+				Environment:    EnvironmentDocker,
+				Launch:         LaunchClaude,
+				EnvPassthrough: []string{"AWS_[A-Z"},
+			},
+			wantErr: "env-passthrough: invalid pattern",
+		},
+		{
+			name: "empty env-passthrough pattern",
+			profile: Profile{
+				Environment:    EnvironmentDocker,
+				Launch:         LaunchClaude,
+				EnvPassthrough: []string{""},
+			},
+			wantErr: "env-passthrough: empty pattern",
+		},
+		{
+			name: "valid worktree fetch mode",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{Fetch: FetchIfMissing},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+		},
+		{
+			name: "unknown worktree fetch mode",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{Fetch: "sometimes"},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+			wantErr: "unknown worktree fetch mode",
+		},
+		{
+			name: "valid worktree submodules and lfs",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{Submodules: SubmodulesRecursive, LFS: true},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+		},
+		{
+			name: "unknown worktree submodules mode",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{Submodules: "shallow"},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+			wantErr: "unknown worktree submodules mode",
+		},
+		{
+			name: "valid worktree sparse directories",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{Sparse: []string{"packages/api", "tools/"}},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+		},
+		{
+			name: "worktree sparse pattern",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{Sparse: []string{"packages/*"}},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+			wantErr: "worktree.sparse: \"packages/*\" must be a directory path",
+		},
+		{
+			name: "worktree sparse outside repository",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{Sparse: []string{".."}},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+			wantErr: "worktree.sparse: \"..\" must be relative",
+		},
+		{
+			name: "valid worktree copy and link patterns",
+			profile: Profile{
+				Worktree: &WorktreeConfig{
+					Copy: []string{".env.local", "config/*.local.yml"},
+					Link: []string{"node_modules"},
+				},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+		},
+		{
+			name: "invalid worktree copy pattern",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{Copy: []string{"config/[a-"}},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+			wantErr: "worktree.copy: invalid pattern",
+		},
+		{
+			name: "absolute worktree link pattern",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{Link: []string{"/etc/passwd"}},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+			wantErr: "worktree.link: pattern \"/etc/passwd\" must be relative",
+		},
+		{
+			name: "worktree copy pattern outside repository",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{Copy: []string{"../secrets/*"}},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+			wantErr: "must be relative to the repository root",
+		},
+		{
+			name: "worktree copy pattern matching the worktree dir",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{Copy: []string{"*"}},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+			wantErr: `worktree.copy: pattern "*" matches the worktree directory "worktrees"`,
+		},
+		{
+			name: "worktree link pattern matching a custom worktree dir",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{Dir: ".aw/{{profile}}", Link: []string{".aw"}},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+			wantErr: `worktree.link: pattern ".aw" matches the worktree directory`,
+		},
+		{
+			name: "worktree copy pattern with worktree dir outside the repository",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{Dir: "../{{repo}}-worktrees", Copy: []string{"*"}},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+		},
+		{
+			name: "on-create in container",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{OnCreate: "pnpm install", OnCreateIn: HookRunInContainer},
+				Environment: EnvironmentDocker,
+				Launch:      LaunchClaude,
+			},
+		},
+		{
+			name: "on-create in container on host profile",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{OnCreate: "pnpm install", OnCreateIn: HookRunInContainer},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+			wantErr: "worktree.on-create-in: container requires environment: docker",
+		},
+		{
+			name: "unknown on-create-in",
+			profile: Profile{
+				Worktree:    &WorktreeConfig{OnCreate: "pnpm install", OnCreateIn: "vm"},
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+			},
+			wantErr: "unknown worktree on-create-in",
+		},
+		{
+			name: "valid hooks",
+			profile: Profile{
+				Environment: EnvironmentDocker,
+				Launch:      LaunchClaude,
+				Hooks: Hooks{
+					HookPreDocker:  {{Run: "make assets", Timeout: "5m"}},
+					HookPostDocker: {{Run: "npm ci", RunIn: HookRunInContainer}},
+					HookOnError:    {{Run: "notify", ContinueOnError: true}},
+				},
+			},
+		},
+		{
+			name: "unknown hook event",
+			profile: Profile{
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+				Hooks:       Hooks{"post-create": {{Run: "make"}}},
+			},
+			wantErr: "hooks: unknown event \"post-create\"",
+		},
+		{
+			name: "hook without run",
+			profile: Profile{
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+				Hooks:       Hooks{HookPreLaunch: {{Timeout: "1m"}}},
+			},
+			wantErr: "hooks.pre-launch[0]: run is required",
+		},
+		{
+			name: "invalid hook timeout",
+			profile: Profile{
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+				Hooks:       Hooks{HookPreLaunch: {{Run: "make", Timeout: "soon"}}},
+			},
+			wantErr: "hooks.pre-launch[0]: invalid timeout \"soon\"",
+		},
+		{
+			name: "unknown hook run-in",
+			profile: Profile{
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+				Hooks:       Hooks{HookPreLaunch: {{Run: "make", RunIn: "vm"}}},
+			},
+			wantErr: "unknown run-in",
+		},
+		{
+			name: "container hook on host profile",
+			profile: Profile{
+				Environment: EnvironmentHost,
+				Launch:      LaunchShell,
+				Hooks:       Hooks{HookPreLaunch: {{Run: "make", RunIn: HookRunInContainer}}},
+			},
+			wantErr: "run-in: container requires environment: docker",
+		},
+		{
+			name: "container hook before docker stage",
+			profile: Profile{
+				Environment: EnvironmentDocker,
+				Launch:      LaunchClaude,
+				Hooks:       Hooks{HookPreDocker: {{Run: "make", RunIn: HookRunInContainer}}},
+			},
+			wantErr: "not available before the docker stage",
+		},
 	}
 
 	for _, tt := range tests {
@@ -113,6 +363,9 @@ func TestValidate(t *testing.T) {
 }
 
 func TestValidateConfig(t *testing.T) {
+	defer func(names []string) { ReservedNames = names }(ReservedNames)
+	ReservedNames = []string{"init"}
+
 	tests := []struct {
 		name    string
 		config  Config
@@ -162,6 +415,45 @@ func TestValidateConfig(t *testing.T) {
 			},
 			wantErr: "config validation errors",
 		},
+		{
+			name: "shared hooks",
+			config: Config{
+				Hooks: Hooks{HookPostDocker: {{Run: "npm ci", RunIn: HookRunInContainer}}},
+				Profiles: map[string]Profile{
+					"test": {Environment: EnvironmentDocker, Launch: LaunchClaude},
+				},
+			},
+		},
+		{
+			name: "shared container hook with a host profile",
+			config: Config{
+				Hooks: Hooks{HookPostDocker: {{Run: "npm ci", RunIn: HookRunInContainer}}},
+				Profiles: map[string]Profile{
+					"docker": {Environment: EnvironmentDocker, Launch: LaunchClaude},
+					"host":   {Environment: EnvironmentHost, Launch: LaunchShell},
+				},
+			},
+			wantErr: `requires environment: docker in profile "host"`,
+		},
+		{
+			name: "invalid shared hook",
+			config: Config{
+				Hooks: Hooks{"pre-lunch": {{Run: "make"}}},
+				Profiles: map[string]Profile{
+					"test": {Environment: EnvironmentHost, Launch: LaunchShell},
+				},
+			},
+			wantErr: `unknown event "pre-lunch"`,
+		},
+		{
+			name: "profile named after a command",
+			config: Config{
+				Profiles: map[string]Profile{
+					"init": {Environment: EnvironmentHost, Launch: LaunchShell},
+				},
+			},
+			wantErr: `profile name "init" is taken by the ` + "`aw init`" + ` command`,
+		},
 		{
 			name: "no default is ok",
 			config: Config{
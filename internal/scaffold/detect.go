@@ -0,0 +1,117 @@
+// Package scaffold generates a starting .agent-workspace.yml for a
+// repository, tailored to the languages and tooling found in it.
+package scaffold
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hiragram/agent-workspace/internal/worktree"
+)
+
+// Language is a toolchain detected in a repository.
+type Language string
+
+const (
+	LanguageGo     Language = "go"
+	LanguageNode   Language = "node"
+	LanguagePython Language = "python"
+	LanguageRust   Language = "rust"
+)
+
+// Project describes what was detected in a repository.
+type Project struct {
+	Root         string
+	Languages    []Language
+	Setup        []string // commands that install dependencies, one per language
+	Copy         []string // ignored files worth bringing into new worktrees
+	Devcontainer string   // path of devcontainer.json relative to Root, if any
+	DevSetup     string   // setup command from devcontainer.json, if any
+	Base         string   // default worktree base, e.g. "origin/main"; empty if only the remote knows it
+}
+
+// languageFiles maps a file in the repository root to the language it
+// indicates and the command that installs its dependencies. The first
+// matching file of a language wins.
+var languageFiles = []struct {
+	file     string
+	language Language
+	setup    string
+}{
+	{"go.mod", LanguageGo, "go mod download"},
+	{"pnpm-lock.yaml", LanguageNode, "pnpm install --frozen-lockfile"},
+	{"yarn.lock", LanguageNode, "yarn install --frozen-lockfile"},
+	{"bun.lockb", LanguageNode, "bun install --frozen-lockfile"},
+	{"bun.lock", LanguageNode, "bun install --frozen-lockfile"},
+	{"package-lock.json", LanguageNode, "npm ci"},
+	{"package.json", LanguageNode, "npm install"},
+	{"uv.lock", LanguagePython, "uv sync"},
+	{"poetry.lock", LanguagePython, "poetry install"},
+	{"Pipfile.lock", LanguagePython, "pipenv install --dev"},
+	{"requirements.txt", LanguagePython, "python3 -m venv .venv && .venv/bin/pip install -r requirements.txt"},
+	{"pyproject.toml", LanguagePython, "python3 -m venv .venv && .venv/bin/pip install -e ."},
+	{"Cargo.toml", LanguageRust, "cargo fetch"},
+}
+
+// copyCandidates are untracked files and directories that a new worktree
+// usually needs: local env files, and dependency caches that make setup
+// fast (copies are cheap on copy-on-write file systems).
+var copyCandidates = []string{
+	".env",
+	".env.local",
+	".env.development.local",
+	".envrc",
+	"node_modules",
+}
+
+// Detect inspects the repository at root.
+func Detect(root string) Project {
+	p := Project{Root: root}
+
+	seen := make(map[Language]bool)
+	for _, lf := range languageFiles {
+		if seen[lf.language] || !exists(filepath.Join(root, lf.file)) {
+			continue
+		}
+		seen[lf.language] = true
+		p.Languages = append(p.Languages, lf.language)
+		p.Setup = append(p.Setup, lf.setup)
+	}
+
+	for _, name := range copyCandidates {
+		if exists(filepath.Join(root, name)) && isIgnored(root, name) {
+			p.Copy = append(p.Copy, name)
+		}
+	}
+
+	if path, cfg, ok := findDevcontainer(root); ok {
+		p.Devcontainer = path
+		p.DevSetup = cfg.setupCommand()
+	}
+
+	p.Base = worktree.LocalDefaultBase(root)
+	return p
+}
+
+// SetupCommand returns the command to run in new worktrees: the
+// devcontainer's setup if there is one, since the project maintains it,
+// and the detected dependency installs otherwise.
+func (p Project) SetupCommand() string {
+	if p.DevSetup != "" {
+		return p.DevSetup
+	}
+	return strings.Join(p.Setup, " && ")
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// isIgnored reports whether git ignores name, i.e. it would not be in a
+// fresh worktree.
+func isIgnored(root, name string) bool {
+	return exec.Command("git", "-C", root, "check-ignore", "-q", name).Run() == nil
+}
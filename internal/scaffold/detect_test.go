@@ -0,0 +1,138 @@
+package scaffold
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	repo := t.TempDir()
+	git(t, repo, "init", "-q", "-b", "trunk")
+	writeFile(t, filepath.Join(repo, "go.mod"), "module example.com/x\n")
+	writeFile(t, filepath.Join(repo, "package.json"), "{}\n")
+	writeFile(t, filepath.Join(repo, "pnpm-lock.yaml"), "")
+	writeFile(t, filepath.Join(repo, ".gitignore"), ".env.local\nnode_modules/\n")
+	writeFile(t, filepath.Join(repo, ".env.local"), "SECRET=1\n")
+	writeFile(t, filepath.Join(repo, ".env"), "TRACKED=1\n") // not ignored
+	writeFile(t, filepath.Join(repo, "node_modules", "x", "index.js"), "")
+
+	p := Detect(repo)
+
+	if !slices.Equal(p.Languages, []Language{LanguageGo, LanguageNode}) {
+		t.Errorf("Languages = %v", p.Languages)
+	}
+	if got := p.SetupCommand(); got != "go mod download && pnpm install --frozen-lockfile" {
+		t.Errorf("SetupCommand() = %q", got)
+	}
+	if !slices.Equal(p.Copy, []string{".env.local", "node_modules"}) {
+		t.Errorf("Copy = %v", p.Copy)
+	}
+	if p.Base != "trunk" {
+		t.Errorf("Base = %q, want the current branch without remotes", p.Base)
+	}
+	if p.Devcontainer != "" {
+		t.Errorf("Devcontainer = %q", p.Devcontainer)
+	}
+}
+
+func TestDetect_RemoteHead(t *testing.T) {
+	src := t.TempDir()
+	git(t, src, "init", "-q", "-b", "develop")
+	git(t, src, "commit", "-q", "--allow-empty", "-m", "init")
+	clone := filepath.Join(t.TempDir(), "clone")
+	git(t, src, "clone", "-q", src, clone)
+
+	if got := Detect(clone).Base; got != "origin/develop" {
+		t.Errorf("Base = %q, want origin/develop", got)
+	}
+
+	// The remote still knows its default branch, but Detect must not ask it
+	git(t, clone, "remote", "set-head", "origin", "--delete")
+	if got := Detect(clone).Base; got != "" {
+		t.Errorf("Base = %q, want none without origin/HEAD", got)
+	}
+}
+
+func TestDetect_Devcontainer(t *testing.T) {
+	repo := t.TempDir()
+	git(t, repo, "init", "-q")
+	writeFile(t, filepath.Join(repo, "Cargo.toml"), "")
+	writeFile(t, filepath.Join(repo, ".devcontainer", "rust", "devcontainer.json"), `{
+  // JSON with comments, as devcontainers allow
+  "image": "mcr.microsoft.com/devcontainers/rust:1", /* base image */
+  "onCreateCommand": "rustup component add clippy",
+  "postCreateCommand": {
+    "deps": ["cargo", "fetch", "--locked"],
+    "hooks": "./scripts/install-hooks.sh"
+  },
+}
+`)
+
+	p := Detect(repo)
+	if p.Devcontainer != ".devcontainer/rust/devcontainer.json" {
+		t.Errorf("Devcontainer = %q", p.Devcontainer)
+	}
+	want := "rustup component add clippy && cargo fetch --locked && ./scripts/install-hooks.sh"
+	if got := p.SetupCommand(); got != want {
+		t.Errorf("SetupCommand() = %q, want %q", got, want)
+	}
+}
+
+func TestLifecycleCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+		want string
+	}{
+		{"string", "make setup", "make setup"},
+		{"argv", []any{"bash", "-c", "echo 'hi' && make"}, `bash -c 'echo '\''hi'\'' && make'`},
+		{"invalid argv", []any{"bash", 1}, ""},
+		{"object", map[string]any{"b": "second", "a": "first"}, "first && second"},
+		{"missing", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lifecycleCommand(tt.in); got != tt.want {
+				t.Errorf("lifecycleCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripJSONC(t *testing.T) {
+	in := `{"url": "https://example.com/*x*/", // comment
+  "list": [1, 2,],
+  /* block */ "s": "a,]"
+}`
+	got := string(stripJSONC([]byte(in)))
+	for _, want := range []string{`"https://example.com/*x*/"`, `[1, 2]`, `"a,]"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("stripJSONC() = %s, want it to contain %s", got, want)
+		}
+	}
+	if strings.Contains(got, "comment") || strings.Contains(got, "block") {
+		t.Errorf("stripJSONC() = %s, want comments removed", got)
+	}
+}
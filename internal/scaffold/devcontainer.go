@@ -0,0 +1,152 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// devcontainerPaths are where devcontainer.json is looked for, in order.
+var devcontainerPaths = []string{
+	".devcontainer/devcontainer.json",
+	".devcontainer.json",
+}
+
+// devcontainer holds the parts of devcontainer.json used by aw init.
+type devcontainer struct {
+	OnCreateCommand      any `json:"onCreateCommand"`
+	UpdateContentCommand any `json:"updateContentCommand"`
+	PostCreateCommand    any `json:"postCreateCommand"`
+}
+
+// findDevcontainer returns the first parsable devcontainer.json in root,
+// including ones in .devcontainer/<name>/ subdirectories.
+func findDevcontainer(root string) (string, devcontainer, bool) {
+	paths := append([]string{}, devcontainerPaths...)
+	if subdirs, err := filepath.Glob(filepath.Join(root, ".devcontainer", "*", "devcontainer.json")); err == nil {
+		sort.Strings(subdirs)
+		for _, p := range subdirs {
+			if rel, err := filepath.Rel(root, p); err == nil {
+				paths = append(paths, rel)
+			}
+		}
+	}
+
+	for _, rel := range paths {
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			continue
+		}
+		var cfg devcontainer
+		if err := json.Unmarshal(stripJSONC(data), &cfg); err != nil {
+			continue
+		}
+		return filepath.ToSlash(rel), cfg, true
+	}
+	return "", devcontainer{}, false
+}
+
+// setupCommand returns the lifecycle commands that set up a fresh checkout
+// (onCreate, updateContent and postCreate), joined with "&&".
+func (d devcontainer) setupCommand() string {
+	var cmds []string
+	for _, c := range []any{d.OnCreateCommand, d.UpdateContentCommand, d.PostCreateCommand} {
+		if s := lifecycleCommand(c); s != "" {
+			cmds = append(cmds, s)
+		}
+	}
+	return strings.Join(cmds, " && ")
+}
+
+// lifecycleCommand converts a devcontainer lifecycle command, which is a
+// shell string, an argv array, or an object of named commands (run in
+// parallel by devcontainers, here in name order), to a shell command.
+func lifecycleCommand(c any) string {
+	switch c := c.(type) {
+	case string:
+		return c
+	case []any:
+		var args []string
+		for _, a := range c {
+			s, ok := a.(string)
+			if !ok {
+				return ""
+			}
+			args = append(args, shellQuote(s))
+		}
+		return strings.Join(args, " ")
+	case map[string]any:
+		names := make([]string, 0, len(c))
+		for name := range c {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var cmds []string
+		for _, name := range names {
+			if s := lifecycleCommand(c[name]); s != "" {
+				cmds = append(cmds, s)
+			}
+		}
+		return strings.Join(cmds, " && ")
+	}
+	return ""
+}
+
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:@") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// stripJSONC removes the comments and trailing commas that devcontainer.json
+// allows but encoding/json does not.
+func stripJSONC(data []byte) []byte {
+	var out []byte
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == '}' || c == ']':
+			// Drop a trailing comma before the closing bracket
+			j := len(out) - 1
+			for j >= 0 && strings.ContainsRune(" \t\r\n", rune(out[j])) {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,152 @@
+package scaffold
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+// Options are the choices that shape the generated config.
+type Options struct {
+	Base        string              // default-base for the worktree profiles
+	Environment profile.Environment // environment of the default profile
+	Launch      profile.LaunchMode  // launch mode of the default profile
+	OnCreate    string              // setup command for new worktrees; empty for none
+	Copy        []string            // worktree.copy entries
+}
+
+// DefaultOptions returns the options suggested for p. dockerAvailable
+// reports whether docker is installed; without it the default profile runs
+// on the host.
+func DefaultOptions(p Project, dockerAvailable bool) Options {
+	env := profile.EnvironmentDocker
+	if !dockerAvailable {
+		env = profile.EnvironmentHost
+	}
+	return Options{
+		Base:        p.Base,
+		Environment: env,
+		Launch:      profile.LaunchClaude,
+		OnCreate:    p.SetupCommand(),
+		Copy:        p.Copy,
+	}
+}
+
+// Render returns the config file for p and opts. It defines three
+// profiles: "dev" (the default) with the chosen environment and launch
+// mode in a new worktree, "shell" for a host shell in a new worktree, and
+// "here" for the chosen environment in the current checkout.
+func Render(p Project, opts Options) []byte {
+	var b strings.Builder
+
+	fmt.Fprintln(&b, "# Generated by aw init. See the configuration guide for all options:")
+	fmt.Fprintln(&b, "# https://github.com/hiragram/agent-workspace/blob/main/docs/configuration.md")
+	if len(p.Languages) > 0 {
+		langs := make([]string, len(p.Languages))
+		for i, l := range p.Languages {
+			langs[i] = string(l)
+		}
+		fmt.Fprintf(&b, "# Detected: %s\n", strings.Join(langs, ", "))
+	}
+	if p.DevSetup != "" && opts.OnCreate == p.DevSetup {
+		fmt.Fprintf(&b, "# Setup commands are taken from %s.\n", p.Devcontainer)
+	}
+	fmt.Fprintf(&b, "version: %d\n", profile.CurrentVersion)
+	fmt.Fprintln(&b, "default: dev")
+	// Without a base, worktrees start from the remote's default branch,
+	// which aw looks up when it creates one
+	base := "the default branch of the remote"
+	if opts.Base != "" {
+		base = opts.Base
+		fmt.Fprintf(&b, "default-base: %s\n", yamlScalar(opts.Base))
+	}
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "profiles:")
+
+	fmt.Fprintf(&b, "  # Create a worktree from %s and %s\n", base, describe(opts.Environment, opts.Launch))
+	fmt.Fprintln(&b, "  dev:")
+	writeWorktree(&b, opts, opts.Environment)
+	writeRun(&b, opts.Environment, opts.Launch)
+	fmt.Fprintln(&b)
+
+	fmt.Fprintf(&b, "  # Create a worktree from %s and open a shell in it\n", base)
+	fmt.Fprintln(&b, "  shell:")
+	writeWorktree(&b, opts, profile.EnvironmentHost)
+	writeRun(&b, profile.EnvironmentHost, profile.LaunchShell)
+	fmt.Fprintln(&b)
+
+	fmt.Fprintf(&b, "  # %s in the current checkout\n", capitalize(describe(opts.Environment, opts.Launch)))
+	fmt.Fprintln(&b, "  here:")
+	writeRun(&b, opts.Environment, opts.Launch)
+
+	return []byte(b.String())
+}
+
+// writeWorktree writes the worktree settings of a profile with the
+// environment env. In Docker, the setup command runs in the container, so
+// that dependencies are built for its platform.
+func writeWorktree(b *strings.Builder, opts Options, env profile.Environment) {
+	if len(opts.Copy) == 0 && opts.OnCreate == "" {
+		fmt.Fprintln(b, "    worktree: {}")
+		return
+	}
+	fmt.Fprintln(b, "    worktree:")
+	if len(opts.Copy) > 0 {
+		fmt.Fprintln(b, "      copy:")
+		for _, c := range opts.Copy {
+			fmt.Fprintf(b, "        - %s\n", yamlScalar(c))
+		}
+	}
+	if opts.OnCreate != "" {
+		fmt.Fprintf(b, "      on-create: %s\n", yamlScalar(opts.OnCreate))
+		if env == profile.EnvironmentDocker {
+			fmt.Fprintf(b, "      on-create-in: %s\n", profile.HookRunInContainer)
+		}
+	}
+}
+
+func writeRun(b *strings.Builder, env profile.Environment, launch profile.LaunchMode) {
+	fmt.Fprintf(b, "    environment: %s\n", env)
+	fmt.Fprintf(b, "    launch: %s\n", launch)
+	if launch == profile.LaunchZellij {
+		fmt.Fprintln(b, "    zellij:")
+		fmt.Fprintln(b, "      layout: default")
+	}
+}
+
+// describe returns what a profile does, e.g. "run Claude Code in Docker".
+func describe(env profile.Environment, launch profile.LaunchMode) string {
+	where := "on the host"
+	if env == profile.EnvironmentDocker {
+		where = "in Docker"
+	}
+	switch launch {
+	case profile.LaunchShell:
+		return "open a shell " + where
+	case profile.LaunchZellij, profile.LaunchTmux:
+		return fmt.Sprintf("start a %s session with Claude Code %s", launch, where)
+	}
+	return "run Claude Code " + where
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// yamlScalar returns s as a YAML scalar, quoted if needed.
+func yamlScalar(s string) string {
+	out, err := yaml.Marshal(s)
+	quoted := strings.TrimSuffix(string(out), "\n")
+	if err != nil || strings.Contains(quoted, "\n") {
+		// Keep multi-line values on one line
+		return strconv.Quote(s)
+	}
+	return quoted
+}
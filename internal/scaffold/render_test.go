@@ -0,0 +1,65 @@
+package scaffold
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+func TestRender(t *testing.T) {
+	p := Project{
+		Languages: []Language{LanguageNode},
+		Setup:     []string{"npm ci"},
+		Copy:      []string{".env.local"},
+		Base:      "origin/develop",
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"defaults", DefaultOptions(p, true)},
+		{"no docker", DefaultOptions(p, false)},
+		{"remote default", Options{Environment: profile.EnvironmentDocker, Launch: profile.LaunchClaude}},
+		{"zellij", Options{Base: "main", Environment: profile.EnvironmentDocker, Launch: profile.LaunchZellij}},
+		{"quoted setup", Options{Base: "origin/main", Environment: profile.EnvironmentHost, Launch: profile.LaunchShell, OnCreate: "echo '# not a comment': done\nmake"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := Render(p, tt.opts)
+			cfg, err := profile.Parse(data)
+			if err != nil {
+				t.Fatalf("Parse() error: %v\n%s", err, data)
+			}
+			if err := profile.ValidateConfig(cfg); err != nil {
+				t.Fatalf("ValidateConfig() error: %v\n%s", err, data)
+			}
+
+			dev := cfg.Profiles[cfg.Default]
+			if cfg.Default != "dev" || dev.Environment != tt.opts.Environment || dev.Launch != tt.opts.Launch {
+				t.Errorf("default profile = %q %+v", cfg.Default, dev)
+			}
+			if cfg.DefaultBase != tt.opts.Base {
+				t.Errorf("default-base = %q, want %q", cfg.DefaultBase, tt.opts.Base)
+			}
+			if dev.Worktree == nil || dev.Worktree.OnCreate != tt.opts.OnCreate {
+				t.Errorf("dev worktree = %+v", dev.Worktree)
+			}
+			if wantIn := tt.opts.Environment == profile.EnvironmentDocker && tt.opts.OnCreate != ""; (dev.Worktree.OnCreateIn == profile.HookRunInContainer) != wantIn {
+				t.Errorf("dev on-create-in = %q, want container: %v", dev.Worktree.OnCreateIn, wantIn)
+			}
+			if len(dev.Worktree.Copy) != len(tt.opts.Copy) {
+				t.Errorf("dev copy = %v, want %v", dev.Worktree.Copy, tt.opts.Copy)
+			}
+			if shell := cfg.Profiles["shell"]; shell.Launch != profile.LaunchShell || shell.Worktree == nil || cfg.Profiles["here"].Worktree != nil {
+				t.Errorf("profiles = %+v", cfg.Profiles)
+			}
+		})
+	}
+
+	if got := string(Render(p, DefaultOptions(p, false))); !strings.Contains(got, "# Detected: node") {
+		t.Errorf("Render() does not list the detected languages:\n%s", got)
+	}
+}
@@ -16,10 +16,11 @@ import (
 	"github.com/hiragram/agent-workspace/internal/pipeline"
 )
 
-const (
-	defaultImageName  = "claude-code-docker"
-	defaultVolumeName = "claude-code-local"
-)
+const defaultImageName = "claude-code-docker"
+
+// DefaultVolumeName is the Docker volume that holds the container's home
+// directory state across runs.
+const DefaultVolumeName = "claude-code-local"
 
 // DockerStage builds the Docker image, creates volumes, syncs config, and builds mounts.
 type DockerStage struct {
@@ -42,7 +43,7 @@ func (s *DockerStage) Name() string { return "docker" }
 func (s *DockerStage) Run(ctx context.Context, ec *pipeline.ExecutionContext) error {
 	// 1. Check Docker availability
 	if err := s.DockerClient.CheckAvailable(); err != nil {
-		return fmt.Errorf("docker is not available: %w", err)
+		return fmt.Errorf("docker is not available: %w (run aw doctor for help)", err)
 	}
 
 	// 2. Resolve custom Dockerfile path
@@ -75,12 +76,18 @@ func (s *DockerStage) Run(ctx context.Context, ec *pipeline.ExecutionContext) er
 	} else {
 		fmt.Fprintf(os.Stderr, "Building Docker image '%s'...\n", imageName)
 	}
-	if err := s.DockerClient.Build(ctx, imageName, buildDir); err != nil {
+	done := ec.StartStep("build")
+	err = s.DockerClient.Build(ctx, imageName, buildDir)
+	done(err)
+	if err != nil {
 		return fmt.Errorf("building image: %w", err)
 	}
 
 	// 3. Create Docker volume
-	if err := s.DockerClient.VolumeCreate(ctx, defaultVolumeName); err != nil {
+	done = ec.StartStep("volume")
+	err = s.DockerClient.VolumeCreate(ctx, DefaultVolumeName)
+	done(err)
+	if err != nil {
 		return fmt.Errorf("creating volume: %w", err)
 	}
 
@@ -89,7 +96,10 @@ func (s *DockerStage) Run(ctx context.Context, ec *pipeline.ExecutionContext) er
 	containerClaudeHome := filepath.Join(ec.HomeDir, ".agent-workspace")
 	containerClaudeJSON := filepath.Join(ec.HomeDir, ".agent-workspace.json")
 
-	if err := s.ConfigSyncer.SyncSettings(claudeHome, containerClaudeHome); err != nil {
+	done = ec.StartStep("sync-settings")
+	err = s.ConfigSyncer.SyncSettings(claudeHome, containerClaudeHome)
+	done(err)
+	if err != nil {
 		return fmt.Errorf("syncing settings: %w", err)
 	}
 
@@ -99,14 +109,16 @@ func (s *DockerStage) Run(ctx context.Context, ec *pipeline.ExecutionContext) er
 	}
 
 	// 6. Build mounts
+	done = ec.StartStep("mounts")
 	mounts, err := s.MountBuilder.BuildMounts(mount.MountOptions{
 		HomeDir:             ec.HomeDir,
 		WorkDir:             ec.WorkDir,
 		ClaudeHome:          claudeHome,
 		ContainerClaudeHome: containerClaudeHome,
 		ContainerClaudeJSON: containerClaudeJSON,
-		VolumeName:          defaultVolumeName,
+		VolumeName:          DefaultVolumeName,
 	})
+	done(err)
 	if err != nil {
 		return fmt.Errorf("building mounts: %w", err)
 	}
@@ -114,7 +126,7 @@ func (s *DockerStage) Run(ctx context.Context, ec *pipeline.ExecutionContext) er
 	// 7. Update execution context
 	ec.DockerImage = imageName
 	ec.DockerMounts = mounts
-	ec.DockerVolume = defaultVolumeName
+	ec.DockerVolume = DefaultVolumeName
 
 	return nil
 }
@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/hiragram/agent-workspace/internal/envfile"
 	"github.com/hiragram/agent-workspace/internal/pipeline"
+	"github.com/hiragram/agent-workspace/internal/profile"
 )
 
 const (
@@ -15,14 +18,19 @@ const (
 	profileEnvFileName = ".aw-profile-env"
 )
 
-// EnvStage loads custom environment variables from the profile config,
-// .aw-profile-env file (written by parent process), and .aw-env file,
-// merging them into the execution context.
+// EnvStage loads custom environment variables from the host environment
+// (env-passthrough), the profile config, .aw-profile-env file (written by
+// parent process), and .aw-env file, merging them into the execution context.
 //
-// Override priority (highest wins):
-//  1. .aw-env (dynamic, from on-create hook)
-//  2. profile.Env (static, from current profile's env field)
-//  3. .aw-profile-env (static, written by parent process's profile env)
+// Sources are applied in this order, each overriding the ones before it:
+//  1. env-passthrough (host environment variables matching the patterns)
+//  2. .aw-profile-env (static, written by parent process's profile env)
+//  3. profile.Env (static, from current profile's env field)
+//  4. .aw-env (dynamic, from on-create hook)
+//
+// The current profile's env is written to .aw-profile-env only in a docker
+// workspace or a worktree, where child processes read it; a host profile
+// without a worktree would otherwise leave the file in the user's checkout.
 type EnvStage struct{}
 
 func (s *EnvStage) Name() string { return "env" }
@@ -30,7 +38,12 @@ func (s *EnvStage) Name() string { return "env" }
 func (s *EnvStage) Run(_ context.Context, ec *pipeline.ExecutionContext) error {
 	merged := make(map[string]string)
 
-	// 1. Start with .aw-profile-env (lowest priority, written by parent process)
+	// 1. Start with host variables matched by env-passthrough (lowest priority)
+	for k, v := range passthroughEnv(ec.Profile.EnvPassthrough, os.Environ()) {
+		merged[k] = v
+	}
+
+	// 2. Overlay with .aw-profile-env (written by parent process)
 	profileEnvFilePath := filepath.Join(ec.WorkDir, profileEnvFileName)
 	profileFileEnv, err := envfile.ParseFile(profileEnvFilePath)
 	if err != nil {
@@ -40,13 +53,13 @@ func (s *EnvStage) Run(_ context.Context, ec *pipeline.ExecutionContext) error {
 		merged[k] = v
 	}
 
-	// 2. Overlay with current profile's env vars
+	// 3. Overlay with current profile's env vars
 	for k, v := range ec.Profile.Env {
 		merged[k] = v
 	}
 
-	// 3. Write current profile env to .aw-profile-env for child processes
-	if len(ec.Profile.Env) > 0 {
+	// Write current profile env to .aw-profile-env for child processes
+	if len(ec.Profile.Env) > 0 && writesProfileEnvFile(ec) {
 		if err := envfile.WriteFile(profileEnvFilePath, ec.Profile.Env); err != nil {
 			return fmt.Errorf("writing %s: %w", profileEnvFileName, err)
 		}
@@ -69,3 +82,31 @@ func (s *EnvStage) Run(_ context.Context, ec *pipeline.ExecutionContext) error {
 	ec.EnvVars = merged
 	return nil
 }
+
+// writesProfileEnvFile reports whether the workspace is one whose child
+// processes read .aw-profile-env: a docker container or a worktree.
+func writesProfileEnvFile(ec *pipeline.ExecutionContext) bool {
+	return ec.Profile.Environment == profile.EnvironmentDocker || ec.WorktreePath != ""
+}
+
+// passthroughEnv returns the variables in environ (KEY=VALUE form) whose
+// names match any of the given glob patterns.
+func passthroughEnv(patterns []string, environ []string) map[string]string {
+	env := make(map[string]string)
+	if len(patterns) == 0 {
+		return env
+	}
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			continue
+		}
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, key); matched {
+				env[key] = value
+				break
+			}
+		}
+	}
+	return env
+}
@@ -121,6 +121,7 @@ func TestEnvStage_WritesProfileEnvFile(t *testing.T) {
 	dir := t.TempDir()
 	ec := &pipeline.ExecutionContext{
 		Profile: profile.Profile{
+			Environment: profile.EnvironmentDocker,
 			Env: map[string]string{
 				"STATIC_KEY": "static_value",
 			},
@@ -237,7 +238,7 @@ func TestEnvStage_AwEnvOverridesAll(t *testing.T) {
 func TestEnvStage_NoWriteWhenProfileEnvEmpty(t *testing.T) {
 	dir := t.TempDir()
 	ec := &pipeline.ExecutionContext{
-		Profile: profile.Profile{}, // no Env
+		Profile: profile.Profile{Environment: profile.EnvironmentDocker}, // no Env
 		WorkDir: dir,
 	}
 
@@ -252,6 +253,47 @@ func TestEnvStage_NoWriteWhenProfileEnvEmpty(t *testing.T) {
 	}
 }
 
+func TestEnvStage_ProfileEnvFileOnlyForChildWorkspaces(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment profile.Environment
+		worktree    bool
+		want        bool
+	}{
+		{"host checkout", profile.EnvironmentHost, false, false},
+		{"host worktree", profile.EnvironmentHost, true, true},
+		{"docker checkout", profile.EnvironmentDocker, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			ec := &pipeline.ExecutionContext{
+				Profile: profile.Profile{
+					Environment: tt.environment,
+					Env:         map[string]string{"KEY": "value"},
+				},
+				WorkDir: dir,
+			}
+			if tt.worktree {
+				ec.WorktreePath = dir
+			}
+
+			s := &EnvStage{}
+			if err := s.Run(context.Background(), ec); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ec.EnvVars["KEY"] != "value" {
+				t.Errorf("KEY = %q, want %q", ec.EnvVars["KEY"], "value")
+			}
+			_, err := os.Stat(filepath.Join(dir, ".aw-profile-env"))
+			if got := err == nil; got != tt.want {
+				t.Errorf(".aw-profile-env written = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestEnvStage_InvalidFileReturnsError(t *testing.T) {
 	dir := t.TempDir()
 	envFile := filepath.Join(dir, ".aw-env")
@@ -270,3 +312,97 @@ func TestEnvStage_InvalidFileReturnsError(t *testing.T) {
 		t.Fatal("expected error for invalid .aw-env file")
 	}
 }
+
+func TestEnvStage_PassthroughFromHost(t *testing.T) {
+	t.Setenv("AW_TEST_PASSTHROUGH_KEY", "secret")
+	t.Setenv("AW_TEST_PASSTHROUGH_OTHER", "other")
+	t.Setenv("AW_TEST_NOT_PASSED", "nope")
+
+	dir := t.TempDir()
+	ec := &pipeline.ExecutionContext{
+		Profile: profile.Profile{
+			EnvPassthrough: []string{"AW_TEST_PASSTHROUGH_*"},
+		},
+		WorkDir: dir,
+	}
+
+	s := &EnvStage{}
+	if err := s.Run(context.Background(), ec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ec.EnvVars["AW_TEST_PASSTHROUGH_KEY"] != "secret" {
+		t.Errorf("AW_TEST_PASSTHROUGH_KEY = %q, want %q", ec.EnvVars["AW_TEST_PASSTHROUGH_KEY"], "secret")
+	}
+	if ec.EnvVars["AW_TEST_PASSTHROUGH_OTHER"] != "other" {
+		t.Errorf("AW_TEST_PASSTHROUGH_OTHER = %q, want %q", ec.EnvVars["AW_TEST_PASSTHROUGH_OTHER"], "other")
+	}
+	if _, ok := ec.EnvVars["AW_TEST_NOT_PASSED"]; ok {
+		t.Error("AW_TEST_NOT_PASSED should not be passed through")
+	}
+
+	// Passthrough values must not be persisted to .aw-profile-env
+	if _, err := os.Stat(filepath.Join(dir, ".aw-profile-env")); !os.IsNotExist(err) {
+		t.Error(".aw-profile-env should not be created for passthrough-only env")
+	}
+}
+
+func TestEnvStage_PassthroughHasLowestPriority(t *testing.T) {
+	t.Setenv("AW_TEST_SHARED", "from-host")
+	t.Setenv("AW_TEST_FILE_SHARED", "from-host")
+
+	dir := t.TempDir()
+	profileEnvFile := filepath.Join(dir, ".aw-profile-env")
+	if err := os.WriteFile(profileEnvFile, []byte("AW_TEST_FILE_SHARED=from-profile-env-file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ec := &pipeline.ExecutionContext{
+		Profile: profile.Profile{
+			EnvPassthrough: []string{"AW_TEST_SHARED", "AW_TEST_FILE_SHARED"},
+			Env: map[string]string{
+				"AW_TEST_SHARED": "from-profile",
+			},
+		},
+		WorkDir: dir,
+	}
+
+	s := &EnvStage{}
+	if err := s.Run(context.Background(), ec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ec.EnvVars["AW_TEST_SHARED"] != "from-profile" {
+		t.Errorf("AW_TEST_SHARED = %q, want %q (profile env should override passthrough)", ec.EnvVars["AW_TEST_SHARED"], "from-profile")
+	}
+	if ec.EnvVars["AW_TEST_FILE_SHARED"] != "from-profile-env-file" {
+		t.Errorf("AW_TEST_FILE_SHARED = %q, want %q (.aw-profile-env should override passthrough)", ec.EnvVars["AW_TEST_FILE_SHARED"], "from-profile-env-file")
+	}
+}
+
+func TestPassthroughEnv(t *testing.T) {
+	environ := []string{
+		"ANTHROPIC_API_KEY=sk-123",
+		"ANTHROPIC_BASE_URL=https://example.com/?a=b",
+		"HTTP_PROXY=http://proxy:3128",
+		"HTTPS_PROXY=http://proxy:3128",
+		"HOME=/home/user",
+		"MALFORMED",
+	}
+
+	got := passthroughEnv([]string{"ANTHROPIC_*", "HTTP_PROXY"}, environ)
+
+	want := map[string]string{
+		"ANTHROPIC_API_KEY":  "sk-123",
+		"ANTHROPIC_BASE_URL": "https://example.com/?a=b",
+		"HTTP_PROXY":         "http://proxy:3128",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d vars (%v), want %d", len(got), got, len(want))
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package stage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/hiragram/agent-workspace/internal/docker"
+	"github.com/hiragram/agent-workspace/internal/launcher"
+	"github.com/hiragram/agent-workspace/internal/pipeline"
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+// HookRunner runs lifecycle hooks on the host or in a one-off container
+// from the workspace image. It implements pipeline.HookRunner.
+type HookRunner struct {
+	DockerClient docker.Client
+}
+
+// NewHookRunner creates a HookRunner with default implementations.
+func NewHookRunner() *HookRunner {
+	return &HookRunner{DockerClient: docker.NewShellClient()}
+}
+
+func (r *HookRunner) RunHook(ctx context.Context, ec *pipeline.ExecutionContext, event string, hook profile.HookCommand, env map[string]string) error {
+	timeout := hook.EffectiveTimeout()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Later entries win: custom env < hook env < workspace/event vars
+	vars := make(map[string]string)
+	for _, m := range []map[string]string{ec.EnvVars, hook.Env, ec.WorkspaceEnv(), env} {
+		for k, v := range m {
+			vars[k] = v
+		}
+	}
+
+	var err error
+	if hook.RunIn == profile.HookRunInContainer {
+		err = r.runInContainer(ctx, ec, hook.Run, vars)
+	} else {
+		err = runHookOnHost(ctx, ec, hook.Run, vars, timeout > 0)
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
+
+// runHookOnHost runs command with sh. Without a timeout, the hook shares
+// aw's terminal like the launched process: it can prompt for input, and
+// Ctrl-C reaches it directly. With one, it runs in its own process group so
+// that the timeout also kills the processes it started; outside the
+// terminal's foreground group it cannot read from it, so stdin is empty.
+func runHookOnHost(ctx context.Context, ec *pipeline.ExecutionContext, command string, vars map[string]string, timeout bool) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	if timeout {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		cmd.Cancel = func() error {
+			return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+	} else {
+		cmd.Stdin = os.Stdin
+	}
+	cmd.Dir = ec.WorkDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	for k, v := range vars {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	return cmd.Run()
+}
+
+func (r *HookRunner) runInContainer(ctx context.Context, ec *pipeline.ExecutionContext, command string, vars map[string]string) error {
+	if ec.DockerImage == "" {
+		return fmt.Errorf("run-in: container requires the docker stage to have run")
+	}
+	cfg := launcher.DockerRunConfig(ec, vars, []string{"sh", "-c", command})
+	cfg.NonInteractive = true
+	return r.DockerClient.Run(ctx, cfg)
+}
@@ -0,0 +1,142 @@
+package stage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hiragram/agent-workspace/internal/docker"
+	"github.com/hiragram/agent-workspace/internal/pipeline"
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+func TestHookRunner_Host(t *testing.T) {
+	workDir := t.TempDir()
+	ec := &pipeline.ExecutionContext{
+		Profile:     profile.Profile{Environment: profile.EnvironmentHost},
+		ProfileName: "dev",
+		WorkDir:     workDir,
+		EnvVars:     map[string]string{"CUSTOM": "custom", "SHARED": "custom"},
+	}
+	hook := profile.HookCommand{
+		Run: `pwd > out.txt && echo "$CUSTOM $SHARED $AW_PROFILE_NAME $AW_HOOK_EVENT" >> out.txt`,
+		Env: map[string]string{"SHARED": "hook"},
+	}
+
+	r := &HookRunner{}
+	err := r.RunHook(context.Background(), ec, profile.HookPreLaunch, hook, map[string]string{"AW_HOOK_EVENT": "pre-launch"})
+	if err != nil {
+		t.Fatalf("RunHook() error: %v", err)
+	}
+
+	out, err := os.ReadFile(filepath.Join(workDir, "out.txt"))
+	if err != nil {
+		t.Fatalf("hook did not run in WorkDir: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	wantDir, _ := filepath.EvalSymlinks(workDir)
+	if gotDir, _ := filepath.EvalSymlinks(lines[0]); gotDir != wantDir {
+		t.Errorf("hook ran in %q, want %q", lines[0], workDir)
+	}
+	if lines[1] != "custom hook dev pre-launch" {
+		t.Errorf("hook env = %q, want %q", lines[1], "custom hook dev pre-launch")
+	}
+}
+
+func TestHookRunner_HostFailure(t *testing.T) {
+	ec := &pipeline.ExecutionContext{WorkDir: t.TempDir()}
+
+	r := &HookRunner{}
+	if err := r.RunHook(context.Background(), ec, profile.HookPreLaunch, profile.HookCommand{Run: "exit 3"}, nil); err == nil {
+		t.Error("RunHook() expected error for failing command, got nil")
+	}
+}
+
+func TestHookRunner_Timeout(t *testing.T) {
+	ec := &pipeline.ExecutionContext{WorkDir: t.TempDir()}
+
+	r := &HookRunner{}
+	err := r.RunHook(context.Background(), ec, profile.HookPreLaunch, profile.HookCommand{Run: "sleep 5", Timeout: "100ms"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("RunHook() error = %v, want timeout", err)
+	}
+}
+
+func TestHookRunner_HostStdin(t *testing.T) {
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.WriteString("yes\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = orig }()
+
+	// e.g. a hook that asks before doing something
+	ec := &pipeline.ExecutionContext{WorkDir: t.TempDir()}
+	hook := profile.HookCommand{Run: `read answer && [ "$answer" = yes ]`}
+	r := &HookRunner{}
+	if err := r.RunHook(context.Background(), ec, profile.HookPreLaunch, hook, nil); err != nil {
+		t.Errorf("RunHook() error = %v, want the hook to read the answer from stdin", err)
+	}
+}
+
+func TestHookRunner_Container(t *testing.T) {
+	client := &mockDockerClient{available: true}
+	mounts := []docker.Mount{{Source: "/repo", Target: "/repo"}}
+	ec := &pipeline.ExecutionContext{
+		Profile:      profile.Profile{Environment: profile.EnvironmentDocker},
+		WorkDir:      "/repo",
+		DockerImage:  "aw-image",
+		DockerMounts: mounts,
+		EnvVars:      map[string]string{"CUSTOM": "custom"},
+	}
+	hook := profile.HookCommand{Run: "npm ci", RunIn: profile.HookRunInContainer}
+
+	r := &HookRunner{DockerClient: client}
+	if err := r.RunHook(context.Background(), ec, profile.HookPostDocker, hook, map[string]string{"AW_HOOK_EVENT": "post-docker"}); err != nil {
+		t.Fatalf("RunHook() error: %v", err)
+	}
+
+	if !client.runCalled {
+		t.Fatal("expected docker run")
+	}
+	cfg := client.runConfig
+	if cfg.ImageName != "aw-image" || cfg.WorkDir != "/repo" || len(cfg.Mounts) != 1 {
+		t.Errorf("RunConfig = %+v", cfg)
+	}
+	if !cfg.NonInteractive {
+		t.Error("expected a non-interactive run")
+	}
+	if strings.Join(cfg.Command, " ") != "sh -c npm ci" {
+		t.Errorf("Command = %v, want [sh -c npm ci]", cfg.Command)
+	}
+	if cfg.EnvVars["CUSTOM"] != "custom" || cfg.EnvVars["AW_HOOK_EVENT"] != "post-docker" {
+		t.Errorf("EnvVars = %v", cfg.EnvVars)
+	}
+	// Like the launched container, the hook runs as the host user
+	if cfg.EnvVars["HOST_WORKSPACE"] != "/repo" || cfg.EnvVars["HOST_CLAUDE_HOME"] == "" {
+		t.Errorf("EnvVars = %v, want HOST_WORKSPACE and HOST_CLAUDE_HOME", cfg.EnvVars)
+	}
+}
+
+func TestHookRunner_ContainerWithoutImage(t *testing.T) {
+	client := &mockDockerClient{available: true}
+	ec := &pipeline.ExecutionContext{WorkDir: t.TempDir()}
+	hook := profile.HookCommand{Run: "npm ci", RunIn: profile.HookRunInContainer}
+
+	r := &HookRunner{DockerClient: client}
+	if err := r.RunHook(context.Background(), ec, profile.HookPostDocker, hook, nil); err == nil {
+		t.Error("RunHook() expected error without a docker image, got nil")
+	}
+	if client.runCalled {
+		t.Error("docker run should not be called without an image")
+	}
+}
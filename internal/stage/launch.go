@@ -2,7 +2,9 @@ package stage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os/exec"
 
 	"github.com/hiragram/agent-workspace/internal/launcher"
 	"github.com/hiragram/agent-workspace/internal/pipeline"
@@ -29,7 +31,16 @@ func (s *LaunchStage) Run(ctx context.Context, ec *pipeline.ExecutionContext) er
 		return err
 	}
 
-	return l.Launch(ctx, ec)
+	// The launched process gets the terminal's signals itself (see
+	// proc.RunForeground), so it must not be killed when aw is interrupted.
+	err = l.Launch(context.WithoutCancel(ctx), ec)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// The workspace was launched and used; keep it even though the
+		// process exited with an error
+		return pipeline.NoRollback(err)
+	}
+	return err
 }
 
 func defaultLauncherFactory(mode profile.LaunchMode) (launcher.Launcher, error) {
@@ -40,6 +51,8 @@ func defaultLauncherFactory(mode profile.LaunchMode) (launcher.Launcher, error)
 		return &launcher.ClaudeLauncher{}, nil
 	case profile.LaunchZellij:
 		return &launcher.ZellijLauncher{}, nil
+	case profile.LaunchTmux:
+		return &launcher.TmuxLauncher{}, nil
 	default:
 		return nil, fmt.Errorf("unknown launch mode: %q", mode)
 	}
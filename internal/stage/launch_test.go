@@ -3,6 +3,7 @@ package stage
 import (
 	"context"
 	"fmt"
+	"os/exec"
 	"strings"
 	"testing"
 
@@ -37,6 +38,7 @@ func TestLaunchStage_Run(t *testing.T) {
 		{"shell launcher", profile.LaunchShell, ""},
 		{"claude launcher", profile.LaunchClaude, ""},
 		{"zellij launcher", profile.LaunchZellij, ""},
+		{"tmux launcher", profile.LaunchTmux, ""},
 		{"unknown launcher", profile.LaunchMode("unknown"), "unknown launch mode"},
 	}
 
@@ -101,3 +103,76 @@ func TestLaunchStage_LauncherError(t *testing.T) {
 		t.Errorf("error = %q, want containing 'launch failed'", err.Error())
 	}
 }
+
+// recordingStage records whether it was rolled back.
+type recordingStage struct {
+	rolledBack bool
+}
+
+func (s *recordingStage) Name() string                                              { return "recording" }
+func (s *recordingStage) Run(_ context.Context, _ *pipeline.ExecutionContext) error { return nil }
+func (s *recordingStage) Rollback(_ context.Context, _ *pipeline.ExecutionContext) error {
+	s.rolledBack = true
+	return nil
+}
+
+func TestLaunchStage_ExitErrorDoesNotRollBack(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 3").Run()
+	tests := []struct {
+		name         string
+		err          error
+		wantRollback bool
+	}{
+		{"launched process exited with error", exitErr, false},
+		{"launcher failed to start", fmt.Errorf("zellij is not installed"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := &recordingStage{}
+			s := &LaunchStage{
+				LauncherFactory: func(_ profile.LaunchMode) (launcher.Launcher, error) {
+					return &mockLauncher{err: tt.err}, nil
+				},
+			}
+
+			p := pipeline.New(before, s)
+			ec := &pipeline.ExecutionContext{Profile: profile.Profile{Launch: profile.LaunchShell}}
+			if err := p.Execute(context.Background(), ec); err == nil {
+				t.Fatal("expected error from launcher")
+			}
+			if before.rolledBack != tt.wantRollback {
+				t.Errorf("rolled back = %v, want %v", before.rolledBack, tt.wantRollback)
+			}
+		})
+	}
+}
+
+// ctxLauncher records whether its context was canceled.
+type ctxLauncher struct {
+	ctxErr error
+}
+
+func (l *ctxLauncher) Launch(ctx context.Context, _ *pipeline.ExecutionContext) error {
+	l.ctxErr = ctx.Err()
+	return nil
+}
+
+func TestLaunchStage_IgnoresCancellation(t *testing.T) {
+	l := &ctxLauncher{}
+	s := &LaunchStage{
+		LauncherFactory: func(_ profile.LaunchMode) (launcher.Launcher, error) {
+			return l, nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ec := &pipeline.ExecutionContext{Profile: profile.Profile{Launch: profile.LaunchShell}}
+	if err := s.Run(ctx, ec); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if l.ctxErr != nil {
+		t.Errorf("launcher context error = %v, want nil", l.ctxErr)
+	}
+}
@@ -0,0 +1,50 @@
+package stage
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/hiragram/agent-workspace/internal/docker"
+	"github.com/hiragram/agent-workspace/internal/launcher"
+	"github.com/hiragram/agent-workspace/internal/pipeline"
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+// OnCreateStage runs the worktree's on-create hook inside the container
+// (worktree.on-create-in: container), so that installed dependencies are
+// built for the container's platform. It must run after DockerStage and
+// before EnvStage, which reads the .aw-env file the hook may write.
+type OnCreateStage struct {
+	DockerClient docker.Client
+}
+
+// NewOnCreateStage creates an OnCreateStage with default implementations.
+func NewOnCreateStage() *OnCreateStage {
+	return &OnCreateStage{DockerClient: docker.NewShellClient()}
+}
+
+func (s *OnCreateStage) Name() string { return "on-create" }
+
+func (s *OnCreateStage) Run(ctx context.Context, ec *pipeline.ExecutionContext) error {
+	cfg := ec.Profile.Worktree
+	if cfg == nil || cfg.OnCreate == "" || cfg.OnCreateIn != profile.HookRunInContainer {
+		return nil
+	}
+	// Resumed worktrees have already been set up
+	if !ec.WorktreeNew {
+		return nil
+	}
+	if ec.DockerImage == "" {
+		return fmt.Errorf("on-create-in: container requires the docker stage to have run")
+	}
+
+	fmt.Fprintf(os.Stderr, "Running on-create hook in container...\n")
+	run := launcher.DockerRunConfig(ec, ec.WorkspaceEnv(), []string{"sh", "-c", cfg.OnCreate})
+	run.WorkDir = ec.WorktreePath
+	run.NonInteractive = true
+	if err := s.DockerClient.Run(ctx, run); err != nil {
+		return fmt.Errorf("on-create hook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package stage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hiragram/agent-workspace/internal/docker"
+	"github.com/hiragram/agent-workspace/internal/pipeline"
+	"github.com/hiragram/agent-workspace/internal/profile"
+)
+
+func TestOnCreateStage_Name(t *testing.T) {
+	s := &OnCreateStage{}
+	if s.Name() != "on-create" {
+		t.Errorf("Name() = %q, want %q", s.Name(), "on-create")
+	}
+}
+
+func onCreateContext() *pipeline.ExecutionContext {
+	return &pipeline.ExecutionContext{
+		Profile: profile.Profile{
+			Worktree: &profile.WorktreeConfig{
+				OnCreate:   "pnpm install",
+				OnCreateIn: profile.HookRunInContainer,
+			},
+			Environment: profile.EnvironmentDocker,
+		},
+		ProfileName:    "dev",
+		WorkDir:        "/repo/worktrees/fox",
+		WorktreePath:   "/repo/worktrees/fox",
+		WorktreeBranch: "fox",
+		WorktreeNew:    true,
+		RepoRoot:       "/repo",
+		DockerImage:    "aw-image",
+		DockerMounts:   []docker.Mount{{Source: "/repo/worktrees/fox", Target: "/repo/worktrees/fox"}},
+	}
+}
+
+func TestOnCreateStage_RunsInContainer(t *testing.T) {
+	client := &mockDockerClient{available: true}
+	s := &OnCreateStage{DockerClient: client}
+
+	if err := s.Run(context.Background(), onCreateContext()); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	if !client.runCalled {
+		t.Fatal("expected docker run")
+	}
+	cfg := client.runConfig
+	if cfg.ImageName != "aw-image" || cfg.WorkDir != "/repo/worktrees/fox" || len(cfg.Mounts) != 1 {
+		t.Errorf("RunConfig = %+v", cfg)
+	}
+	if !cfg.NonInteractive {
+		t.Error("expected a non-interactive run")
+	}
+	if strings.Join(cfg.Command, " ") != "sh -c pnpm install" {
+		t.Errorf("Command = %v, want [sh -c pnpm install]", cfg.Command)
+	}
+	if cfg.EnvVars["AW_WORKTREE_BRANCH"] != "fox" || cfg.EnvVars["AW_REPO_ROOT"] != "/repo" {
+		t.Errorf("EnvVars = %v", cfg.EnvVars)
+	}
+	// Without these, entrypoint.sh would not map the host user
+	if cfg.EnvVars["HOST_WORKSPACE"] != "/repo/worktrees/fox" || cfg.EnvVars["HOST_CLAUDE_HOME"] == "" {
+		t.Errorf("EnvVars = %v, want HOST_WORKSPACE and HOST_CLAUDE_HOME", cfg.EnvVars)
+	}
+}
+
+func TestOnCreateStage_Skips(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(ec *pipeline.ExecutionContext)
+	}{
+		{"resumed worktree", func(ec *pipeline.ExecutionContext) { ec.WorktreeNew = false }},
+		{"on-create on host", func(ec *pipeline.ExecutionContext) { ec.Profile.Worktree.OnCreateIn = "" }},
+		{"no on-create", func(ec *pipeline.ExecutionContext) { ec.Profile.Worktree.OnCreate = "" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockDockerClient{available: true}
+			s := &OnCreateStage{DockerClient: client}
+			ec := onCreateContext()
+			tt.modify(ec)
+
+			if err := s.Run(context.Background(), ec); err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if client.runCalled {
+				t.Error("docker run should not be called")
+			}
+		})
+	}
+}
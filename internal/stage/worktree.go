@@ -1,73 +1,134 @@
 package stage
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/hiragram/agent-workspace/internal/pipeline"
+	"github.com/hiragram/agent-workspace/internal/proc"
+	"github.com/hiragram/agent-workspace/internal/profile"
 	"github.com/hiragram/agent-workspace/internal/worktree"
 )
 
 // WorktreeStage creates a git worktree for the workspace.
-type WorktreeStage struct{}
+type WorktreeStage struct {
+	// Set by Run for Rollback: the checkout created in this run
+	created  *checkout
+	repoRoot string
+}
 
 func (s *WorktreeStage) Name() string { return "worktree" }
 
-func (s *WorktreeStage) Run(_ context.Context, ec *pipeline.ExecutionContext) error {
+func (s *WorktreeStage) Run(ctx context.Context, ec *pipeline.ExecutionContext) error {
+	// Fail before creating a worktree that could not be launched
+	var deps []string
+	if m := ec.Profile.Launch.Multiplexer(); m != "" {
+		deps = append(deps, m)
+	}
+	if err := worktree.CheckRequiredDeps(deps...); err != nil {
+		return err
+	}
+
 	// Find git repository root
 	repoRoot, err := gitRepoRoot()
 	if err != nil {
 		return fmt.Errorf("not in a git repository: %w", err)
 	}
 
-	// Generate random branch name
-	name, err := worktree.GenerateName()
-	if err != nil {
-		return fmt.Errorf("generating branch name: %w", err)
+	if ec.Resume {
+		return resumeWorktree(ec)
 	}
 
-	// Determine base ref
-	base := "origin/main"
-	if ec.Profile.Worktree != nil {
-		base = ec.Profile.Worktree.EffectiveBase()
+	cfg := ec.Profile.Worktree
+	if cfg == nil {
+		cfg = &profile.WorktreeConfig{}
 	}
 
-	// Fetch the base ref
-	refParts := strings.SplitN(base, "/", 2)
-	if len(refParts) == 2 {
-		fmt.Fprintf(os.Stderr, "Fetching %s...\n", base)
-		if err := gitFetch(repoRoot, refParts[0], refParts[1]); err != nil {
-			return fmt.Errorf("fetching %s: %w", base, err)
-		}
+	// Decide which branch to check out and where
+	co, err := planCheckout(ctx, ec, cfg, repoRoot)
+	if err != nil {
+		return err
 	}
 
-	// Create worktrees directory
-	worktreesDir := filepath.Join(repoRoot, "worktrees")
-	if err := os.MkdirAll(worktreesDir, 0755); err != nil {
+	// Create parent directory
+	if err := os.MkdirAll(filepath.Dir(co.path), 0755); err != nil {
 		return fmt.Errorf("creating worktrees directory: %w", err)
 	}
 
 	// Create worktree
-	worktreePath := filepath.Join(worktreesDir, name)
-	fmt.Fprintf(os.Stderr, "Creating worktree: worktrees/%s\n", name)
-	if err := gitWorktreeAdd(repoRoot, name, worktreePath, base); err != nil {
+	fmt.Fprintf(os.Stderr, "Creating worktree: %s\n", displayPath(repoRoot, co.path))
+	addArgs := co.addArgs
+	if len(cfg.Sparse) > 0 {
+		// Check out only after the sparse patterns are set
+		addArgs = append([]string{"--no-checkout"}, addArgs...)
+	}
+	done := ec.StartStep("worktree-add")
+	err = gitWorktreeAdd(ctx, repoRoot, addArgs...)
+	done(err)
+	if err != nil {
 		return fmt.Errorf("creating worktree: %w", err)
 	}
+	s.created, s.repoRoot = co, repoRoot
+	worktreePath, name := co.path, co.branch
+
+	if len(cfg.Sparse) > 0 {
+		fmt.Fprintf(os.Stderr, "Checking out %s (sparse)...\n", strings.Join(cfg.Sparse, ", "))
+		done := ec.StartStep("sparse-checkout")
+		err := gitSparseCheckout(ctx, worktreePath, cfg.Sparse)
+		done(err)
+		if err != nil {
+			return fmt.Errorf("sparse checkout: %w", err)
+		}
+	}
+
+	// Record the worktree so that it can be resumed later
+	now := time.Now()
+	if err := worktree.WriteMetadata(worktreePath, worktree.Metadata{
+		Profile:    ec.ProfileName,
+		Branch:     name,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		RepoRoot:   repoRoot,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
 
 	// Update execution context
 	ec.WorkDir = worktreePath
 	ec.WorktreePath = worktreePath
 	ec.WorktreeBranch = name
 	ec.RepoRoot = repoRoot
+	ec.WorktreeNew = true
 
-	// Run on-create hook if configured
-	if ec.Profile.Worktree != nil && ec.Profile.Worktree.OnCreate != "" {
+	if err := setupSubmodulesAndLFS(ctx, ec, cfg, worktreePath); err != nil {
+		return err
+	}
+
+	// Bring over untracked/ignored files from the main checkout
+	if len(cfg.Copy) > 0 || len(cfg.Link) > 0 {
+		done := ec.StartStep("copy")
+		err := copyIntoWorktree(cfg, repoRoot, worktreePath)
+		done(err)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Run on-create hook if configured. Hooks that run in the container are
+	// run by OnCreateStage once the image is built.
+	if ec.Profile.Worktree != nil && ec.Profile.Worktree.OnCreate != "" && ec.Profile.Worktree.OnCreateIn != profile.HookRunInContainer {
 		fmt.Fprintf(os.Stderr, "Running on-create hook...\n")
-		if err := runOnCreateHook(ec, repoRoot); err != nil {
+		done := ec.StartStep("on-create")
+		err := runOnCreateHook(ec)
+		done(err)
+		if err != nil {
 			return fmt.Errorf("on-create hook: %w", err)
 		}
 	}
@@ -75,37 +136,485 @@ func (s *WorktreeStage) Run(_ context.Context, ec *pipeline.ExecutionContext) er
 	return nil
 }
 
-// execCommand is a package-level var for testing.
-var execCommand = exec.Command
+// Rollback removes the worktree created by Run, and the branch if Run
+// created it. Resumed worktrees and existing branches are left alone.
+func (s *WorktreeStage) Rollback(_ context.Context, ec *pipeline.ExecutionContext) error {
+	co := s.created
+	if co == nil {
+		return nil
+	}
+	s.created = nil
 
-func runOnCreateHook(ec *pipeline.ExecutionContext, repoRoot string) error {
-	cmd := execCommand("sh", "-c", ec.Profile.Worktree.OnCreate)
-	cmd.Dir = ec.WorktreePath
+	fmt.Fprintf(os.Stderr, "Removing worktree: %s\n", displayPath(s.repoRoot, co.path))
+	if err := removeWorktree(s.repoRoot, co.path); err != nil {
+		return err
+	}
+	if co.newBranch {
+		fmt.Fprintf(os.Stderr, "Deleting branch: %s\n", co.branch)
+		if out, err := exec.Command("git", "-C", s.repoRoot, "branch", "-D", co.branch).CombinedOutput(); err != nil {
+			return fmt.Errorf("deleting branch %s: %s", co.branch, strings.TrimSpace(string(out)))
+		}
+	}
+
+	ec.WorkDir = ec.OrigWorkDir
+	ec.WorktreePath = ""
+	ec.WorktreeBranch = ""
+	ec.WorktreeNew = false
+	return nil
+}
+
+// removeWorktree deletes a worktree including untracked files. git refuses
+// to remove worktrees with submodules, so those are deleted directly and
+// pruned.
+func removeWorktree(repoRoot, path string) error {
+	if err := exec.Command("git", "-C", repoRoot, "worktree", "remove", "--force", "--force", path).Run(); err == nil {
+		return nil
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("removing worktree %s: %w", path, err)
+	}
+	if out, err := exec.Command("git", "-C", repoRoot, "worktree", "prune").CombinedOutput(); err != nil {
+		return fmt.Errorf("pruning worktrees: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// setupSubmodulesAndLFS applies worktree.submodules and worktree.lfs.
+// Submodules come first so that LFS files inside them are pulled too.
+func setupSubmodulesAndLFS(ctx context.Context, ec *pipeline.ExecutionContext, cfg *profile.WorktreeConfig, worktreePath string) error {
+	recursive := cfg.Submodules == profile.SubmodulesRecursive
+	if recursive {
+		fmt.Fprintf(os.Stderr, "Initializing submodules...\n")
+		done := ec.StartStep("submodules")
+		err := gitIn(ctx, worktreePath, "submodule", "update", "--init", "--recursive", "--progress")
+		done(err)
+		if err != nil {
+			return fmt.Errorf("initializing submodules: %w", err)
+		}
+	}
+
+	if cfg.LFS {
+		if exec.Command("git", "lfs", "version").Run() != nil {
+			return fmt.Errorf("worktree.lfs is set but git-lfs is not installed (https://git-lfs.com)")
+		}
+		fmt.Fprintf(os.Stderr, "Fetching Git LFS files...\n")
+		done := ec.StartStep("lfs")
+		err := pullLFS(ctx, worktreePath, recursive)
+		done(err)
+		return err
+	}
+	return nil
+}
+
+func pullLFS(ctx context.Context, worktreePath string, recursive bool) error {
+	if err := gitIn(ctx, worktreePath, "lfs", "pull"); err != nil {
+		return fmt.Errorf("git lfs pull: %w", err)
+	}
+	if recursive {
+		if err := gitIn(ctx, worktreePath, "submodule", "foreach", "--recursive", "git lfs pull"); err != nil {
+			return fmt.Errorf("git lfs pull in submodules: %w", err)
+		}
+	}
+	return nil
+}
+
+// gitIn runs a git command in dir, showing its output.
+func gitIn(ctx context.Context, dir string, args ...string) error {
+	cmd := proc.Interruptible(exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...))
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	cmd.Env = append(os.Environ(),
-		"AW_WORKTREE_PATH="+ec.WorktreePath,
-		"AW_WORKTREE_BRANCH="+ec.WorktreeBranch,
-		"AW_REPO_ROOT="+repoRoot,
-		"AW_PROFILE_NAME="+ec.ProfileName,
-		"AW_ENVIRONMENT="+string(ec.Profile.Environment),
-	)
 	return cmd.Run()
 }
 
+// copyIntoWorktree applies worktree.copy and worktree.link.
+func copyIntoWorktree(cfg *profile.WorktreeConfig, repoRoot, worktreePath string) error {
+	if len(cfg.Copy) > 0 {
+		copied, err := worktree.CopyFiles(repoRoot, worktreePath, cfg.Copy)
+		if err != nil {
+			return fmt.Errorf("copying files into worktree: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Copied %d path(s) from the main checkout\n", len(copied))
+	}
+	if len(cfg.Link) > 0 {
+		linked, err := worktree.LinkFiles(repoRoot, worktreePath, cfg.Link)
+		if err != nil {
+			return fmt.Errorf("linking files into worktree: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Linked %d path(s) from the main checkout\n", len(linked))
+	}
+	return nil
+}
+
+// resumeWorktree points the execution context at an existing aw-created
+// worktree instead of creating a new one. The on-create hook is not run.
+func resumeWorktree(ec *pipeline.ExecutionContext) error {
+	info, err := findResumable(ec)
+	if err != nil {
+		return err
+	}
+
+	// The branch may have been switched since the worktree was created
+	branch := info.Branch
+	if out, err := exec.Command("git", "-C", info.Path, "branch", "--show-current").Output(); err == nil {
+		if b := strings.TrimSpace(string(out)); b != "" {
+			branch = b
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Resuming worktree: %s\n", displayPath(info.RepoRoot, info.Path))
+
+	info.LastUsedAt = time.Now()
+	if err := worktree.WriteMetadata(info.Path, info.Metadata); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	ec.WorkDir = info.Path
+	ec.WorktreePath = info.Path
+	ec.WorktreeBranch = branch
+	ec.RepoRoot = info.RepoRoot
+	return nil
+}
+
+// findResumable returns the worktree named by ec.ResumeName, or the most
+// recently used worktree created with the current profile.
+func findResumable(ec *pipeline.ExecutionContext) (worktree.Info, error) {
+	dir := ec.OrigWorkDir
+	if dir == "" {
+		dir = "."
+	}
+	if ec.ResumeName != "" {
+		return worktree.Find(dir, ec.ResumeName)
+	}
+
+	infos, err := worktree.List(dir)
+	if err != nil {
+		return worktree.Info{}, err
+	}
+	for _, info := range infos {
+		if info.Profile == ec.ProfileName {
+			return info, nil
+		}
+	}
+	return worktree.Info{}, fmt.Errorf("no worktree to resume for profile %q", ec.ProfileName)
+}
+
+// checkout describes the worktree to create.
+type checkout struct {
+	branch    string   // branch checked out in the worktree
+	path      string   // worktree path
+	addArgs   []string // arguments for `git worktree add`
+	newBranch bool     // whether `git worktree add` creates the branch
+}
+
+// planCheckout decides how to create the worktree:
+//   - with --pr, a new branch "pr-<N>" is created from the pull request head;
+//   - with --branch naming an existing local branch, it is checked out;
+//   - with --branch naming a branch on a remote, a local branch tracking it
+//     is created;
+//   - otherwise a new branch is created from the base ref: worktree.base,
+//     the config's default-base, or the default branch of the remote.
+func planCheckout(ctx context.Context, ec *pipeline.ExecutionContext, cfg *profile.WorktreeConfig, repoRoot string) (*checkout, error) {
+	if ec.PR != 0 {
+		return prCheckout(ctx, ec, cfg, repoRoot)
+	}
+	if ec.Branch != "" {
+		co, err := existingCheckout(ctx, ec, cfg, repoRoot)
+		if err != nil || co != nil {
+			return co, err
+		}
+	}
+
+	done := ec.StartStep("fetch")
+	base := cfg.EffectiveBase(ec.DefaultBase)
+	if base == "" {
+		base = worktree.DefaultBase(ctx, repoRoot)
+	}
+	err := fetchBase(ctx, repoRoot, base, cfg.EffectiveFetch())
+	done(err)
+	if err != nil {
+		return nil, err
+	}
+
+	name, path, err := newBranch(ec, cfg, repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	return &checkout{branch: name, path: path, addArgs: []string{"-b", name, path, base}, newBranch: true}, nil
+}
+
+// fetchBase makes sure base is available locally. A base on a remote is
+// fetched according to mode; if fetching fails (e.g. when offline) but the
+// base was fetched before, the last fetched ref is used with a warning.
+// Local branches, tags and commits are never fetched.
+func fetchBase(ctx context.Context, repoRoot, base string, mode profile.FetchMode) error {
+	b, err := worktree.ResolveBase(repoRoot, base)
+	if err != nil {
+		return err
+	}
+	if b.Remote == "" {
+		if !worktree.CommitExists(repoRoot, base) {
+			return fmt.Errorf("base %q not found (not a remote branch, local branch, tag or commit)", base)
+		}
+		return nil
+	}
+
+	fetched := worktree.RefExists(repoRoot, b.TrackingRef())
+	switch {
+	case mode == profile.FetchNever && !fetched:
+		return fmt.Errorf("base %q has not been fetched (worktree.fetch is %q; run `git fetch %s %s`)", base, mode, b.Remote, b.Branch)
+	case mode == profile.FetchNever, mode == profile.FetchIfMissing && fetched:
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Fetching %s...\n", base)
+	if err := gitFetch(ctx, repoRoot, b.Remote, "+refs/heads/"+b.Branch+":"+b.TrackingRef()); err != nil {
+		if !fetched {
+			return fmt.Errorf("fetching %s: %w", base, err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: fetching %s failed; using the last fetched %s\n", base, base)
+	}
+	return nil
+}
+
+// existingCheckout returns the checkout for the branch given with --branch if
+// it exists locally or on a remote, and nil if it has to be created.
+func existingCheckout(ctx context.Context, ec *pipeline.ExecutionContext, cfg *profile.WorktreeConfig, repoRoot string) (*checkout, error) {
+	name := ec.Branch
+	if err := worktree.CheckBranchName(name); err != nil {
+		return nil, err
+	}
+
+	if worktree.RefExists(repoRoot, "refs/heads/"+name) {
+		path, err := newWorktreePath(ec, cfg, repoRoot, name)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "Using existing branch %s\n", name)
+		return &checkout{branch: name, path: path, addArgs: []string{path, name}}, nil
+	}
+
+	done := ec.StartStep("fetch")
+	remote, err := remoteWithBranch(ctx, repoRoot, name)
+	done(err)
+	if err != nil || remote == "" {
+		return nil, err
+	}
+	path, err := newWorktreePath(ec, cfg, repoRoot, name)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Using remote branch %s/%s\n", remote, name)
+	return &checkout{
+		branch:    name,
+		path:      path,
+		addArgs:   []string{"--track", "-b", name, path, remote + "/" + name},
+		newBranch: true,
+	}, nil
+}
+
+// remoteWithBranch returns the remote that has the given branch, or "" if
+// none does. Already fetched remote-tracking branches are checked first;
+// otherwise the branch is fetched from the default remote ("origin", or the
+// first remote if there is no origin). It fails if the remote cannot be
+// asked, rather than taking the branch to be missing from it.
+func remoteWithBranch(ctx context.Context, repoRoot, name string) (string, error) {
+	remotes, err := worktree.Remotes(repoRoot)
+	if err != nil {
+		return "", err
+	}
+	if len(remotes) == 0 {
+		return "", nil
+	}
+	remote := worktree.DefaultRemote(remotes)
+
+	for _, r := range remotes {
+		if worktree.RefExists(repoRoot, "refs/remotes/"+r+"/"+name) {
+			return r, nil
+		}
+	}
+
+	refspec := fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", name, remote, name)
+	var stderr bytes.Buffer
+	fetch := proc.Interruptible(exec.CommandContext(ctx, "git", "-C", repoRoot, "fetch", "--quiet", remote, refspec))
+	fetch.Stderr = &stderr
+	err = fetch.Run()
+	if err == nil {
+		return remote, nil
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	// The fetch fails the same way when the remote cannot be reached; only
+	// a remote that answers without the branch (exit code 2) lacks it
+	lsRemote := proc.Interruptible(exec.CommandContext(ctx, "git", "-C", repoRoot, "ls-remote", "--exit-code", "--heads", remote, "refs/heads/"+name))
+	var exitErr *exec.ExitError
+	if lsErr := lsRemote.Run(); errors.As(lsErr, &exitErr) && exitErr.ExitCode() == 2 {
+		return "", nil
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return "", fmt.Errorf("looking up branch %s on %s: %w: %s", name, remote, err, msg)
+	}
+	return "", fmt.Errorf("looking up branch %s on %s: %w", name, remote, err)
+}
+
+// prCheckout fetches the head of a GitHub pull request from the default
+// remote and returns a checkout creating branch "pr-<N>" from it.
+func prCheckout(ctx context.Context, ec *pipeline.ExecutionContext, cfg *profile.WorktreeConfig, repoRoot string) (*checkout, error) {
+	remotes, err := worktree.Remotes(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	if len(remotes) == 0 {
+		return nil, fmt.Errorf("--pr requires a git remote")
+	}
+	remote := worktree.DefaultRemote(remotes)
+
+	name := fmt.Sprintf("%spr-%d", cfg.BranchPrefix, ec.PR)
+	if worktree.RefExists(repoRoot, "refs/heads/"+name) {
+		return nil, fmt.Errorf("branch %q already exists (open it with --branch %s, or delete it to fetch the pull request again)", name, name)
+	}
+	path, err := newWorktreePath(ec, cfg, repoRoot, name)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := fmt.Sprintf("refs/remotes/%s/pr/%d", remote, ec.PR)
+	fmt.Fprintf(os.Stderr, "Fetching pull request #%d from %s...\n", ec.PR, remote)
+	done := ec.StartStep("fetch")
+	err = gitFetch(ctx, repoRoot, remote, fmt.Sprintf("+refs/pull/%d/head:%s", ec.PR, ref))
+	done(err)
+	if err != nil {
+		return nil, fmt.Errorf("fetching pull request #%d: %w", ec.PR, err)
+	}
+	return &checkout{branch: name, path: path, addArgs: []string{"--no-track", "-b", name, path, ref}, newBranch: true}, nil
+}
+
+// newWorktreePath resolves the worktree path for branch and checks that it
+// does not exist yet.
+func newWorktreePath(ec *pipeline.ExecutionContext, cfg *profile.WorktreeConfig, repoRoot, branch string) (string, error) {
+	path, err := worktreePath(ec, cfg, repoRoot, branch)
+	if err != nil {
+		return "", err
+	}
+	if pathExists(path) {
+		return "", fmt.Errorf("worktree path already exists: %s", path)
+	}
+	return path, nil
+}
+
+// worktreePath resolves the worktree path for branch from worktree.dir.
+func worktreePath(ec *pipeline.ExecutionContext, cfg *profile.WorktreeConfig, repoRoot, branch string) (string, error) {
+	path, err := worktree.Path(cfg.EffectiveDir(), worktree.PathVars{
+		RepoRoot: repoRoot,
+		HomeDir:  ec.HomeDir,
+		Name:     worktree.DirName(branch),
+		Profile:  ec.ProfileName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("resolving worktree path: %w", err)
+	}
+	return path, nil
+}
+
+// maxNameAttempts is how many generated branch names are tried before
+// giving up when they collide with existing branches or directories.
+const maxNameAttempts = 5
+
+// newBranch picks the name of the new branch to create and the path of its
+// worktree. A name given with --branch is used as-is and must not exist yet;
+// names generated from a template containing {{words}} are retried on
+// collision.
+func newBranch(ec *pipeline.ExecutionContext, cfg *profile.WorktreeConfig, repoRoot string) (string, string, error) {
+	tmpl := cfg.EffectiveBranch()
+	attempts := 1
+	if ec.Branch == "" && worktree.IsRandomTemplate(tmpl) {
+		attempts = maxNameAttempts
+	}
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		name := ec.Branch
+		if name == "" {
+			var err error
+			name, err = worktree.BranchName(tmpl, cfg.BranchPrefix, worktree.BranchVars{
+				User:    worktree.CurrentUser(),
+				Ticket:  ec.Ticket,
+				Profile: ec.ProfileName,
+				Now:     time.Now(),
+			})
+			if err != nil {
+				return "", "", fmt.Errorf("generating branch name: %w", err)
+			}
+		}
+		if err := worktree.CheckBranchName(name); err != nil {
+			return "", "", err
+		}
+
+		path, err := worktreePath(ec, cfg, repoRoot, name)
+		if err != nil {
+			return "", "", err
+		}
+
+		exists, err := worktree.BranchExists(repoRoot, name)
+		if err != nil {
+			return "", "", err
+		}
+		switch {
+		case exists:
+			lastErr = fmt.Errorf("branch %q already exists", name)
+		case pathExists(path):
+			lastErr = fmt.Errorf("worktree path already exists: %s", path)
+		default:
+			return name, path, nil
+		}
+	}
+	if attempts > 1 {
+		return "", "", fmt.Errorf("%w (gave up after %d attempts)", lastErr, attempts)
+	}
+	return "", "", lastErr
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// displayPath returns path relative to repoRoot if it is inside the
+// repository, and the absolute path otherwise.
+func displayPath(repoRoot, path string) string {
+	if rel, err := filepath.Rel(repoRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// execCommand is a package-level var for testing.
+var execCommand = exec.Command
+
+func runOnCreateHook(ec *pipeline.ExecutionContext) error {
+	return runWorktreeHook(ec, ec.Profile.Worktree.OnCreate)
+}
+
 // RunOnEndHook runs the on-end hook command after the launched process exits.
 func RunOnEndHook(ec *pipeline.ExecutionContext) error {
-	cmd := execCommand("sh", "-c", ec.Profile.Worktree.OnEnd)
+	return runWorktreeHook(ec, ec.Profile.Worktree.OnEnd)
+}
+
+// runWorktreeHook runs command in the worktree on the host, with the AW_*
+// workspace variables.
+func runWorktreeHook(ec *pipeline.ExecutionContext, command string) error {
+	cmd := execCommand("sh", "-c", command)
 	cmd.Dir = ec.WorktreePath
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	cmd.Env = append(os.Environ(),
-		"AW_WORKTREE_PATH="+ec.WorktreePath,
-		"AW_WORKTREE_BRANCH="+ec.WorktreeBranch,
-		"AW_REPO_ROOT="+ec.RepoRoot,
-		"AW_PROFILE_NAME="+ec.ProfileName,
-		"AW_ENVIRONMENT="+string(ec.Profile.Environment),
-	)
+	cmd.Env = os.Environ()
+	for k, v := range ec.WorkspaceEnv() {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
 	return cmd.Run()
 }
 
@@ -118,17 +627,26 @@ func gitRepoRoot() (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
-func gitFetch(repoRoot, remote, ref string) error {
-	cmd := exec.Command("git", "-C", repoRoot, "fetch", remote, ref)
+func gitFetch(ctx context.Context, repoRoot, remote, ref string) error {
+	cmd := proc.Interruptible(exec.CommandContext(ctx, "git", "-C", repoRoot, "fetch", remote, ref))
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func gitWorktreeAdd(repoRoot, branchName, worktreePath, base string) error {
-	cmd := exec.Command("git", "-C", repoRoot, "worktree", "add",
-		"-b", branchName, worktreePath, base)
+func gitWorktreeAdd(ctx context.Context, repoRoot string, args ...string) error {
+	cmd := proc.Interruptible(exec.CommandContext(ctx, "git", append([]string{"-C", repoRoot, "worktree", "add"}, args...)...))
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
+
+// gitSparseCheckout enables cone-mode sparse checkout of dirs in a worktree
+// created with --no-checkout, then checks out the files. The sparse settings
+// are stored per worktree, so the main checkout is not affected.
+func gitSparseCheckout(ctx context.Context, worktreePath string, dirs []string) error {
+	if err := gitIn(ctx, worktreePath, append([]string{"sparse-checkout", "set", "--cone", "--"}, dirs...)...); err != nil {
+		return err
+	}
+	return gitIn(ctx, worktreePath, "checkout")
+}
@@ -1,7 +1,11 @@
 package stage
 
 import (
+	"context"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/hiragram/agent-workspace/internal/pipeline"
@@ -30,7 +34,8 @@ func TestRunOnCreateHook_ShellInvocation(t *testing.T) {
 		WorktreeBranch: "test-branch",
 	}
 
-	err := runOnCreateHook(ec, "/fake/repo")
+	ec.RepoRoot = "/fake/repo"
+	err := runOnCreateHook(ec)
 	if err != nil {
 		t.Fatalf("runOnCreateHook() error: %v", err)
 	}
@@ -71,7 +76,8 @@ func TestRunOnCreateHook_SetsEnvironmentAndDir(t *testing.T) {
 	// Use a command that checks env vars exist
 	ec.Profile.Worktree.OnCreate = "test -n \"$AW_WORKTREE_PATH\" && test -n \"$AW_WORKTREE_BRANCH\" && test -n \"$AW_REPO_ROOT\" && test -n \"$AW_PROFILE_NAME\" && test -n \"$AW_ENVIRONMENT\""
 
-	err := runOnCreateHook(ec, "/fake/repo")
+	ec.RepoRoot = "/fake/repo"
+	err := runOnCreateHook(ec)
 	if err != nil {
 		t.Fatalf("runOnCreateHook() error (env vars missing): %v", err)
 	}
@@ -94,7 +100,8 @@ func TestRunOnCreateHook_EnvVarValues(t *testing.T) {
 		WorktreeBranch: "my-branch",
 	}
 
-	err := runOnCreateHook(ec, "/some/repo")
+	ec.RepoRoot = "/some/repo"
+	err := runOnCreateHook(ec)
 	if err != nil {
 		t.Fatalf("runOnCreateHook() env var values mismatch: %v", err)
 	}
@@ -118,7 +125,8 @@ func TestRunOnCreateHook_WorkingDirectory(t *testing.T) {
 		WorktreeBranch: "branch",
 	}
 
-	err := runOnCreateHook(ec, "/repo")
+	ec.RepoRoot = "/repo"
+	err := runOnCreateHook(ec)
 	if err != nil {
 		t.Fatalf("runOnCreateHook() working directory mismatch: %v", err)
 	}
@@ -140,7 +148,8 @@ func TestRunOnCreateHook_FailureReturnsError(t *testing.T) {
 		WorktreeBranch: "branch",
 	}
 
-	err := runOnCreateHook(ec, "/repo")
+	ec.RepoRoot = "/repo"
+	err := runOnCreateHook(ec)
 	if err == nil {
 		t.Fatal("expected error from failing hook, got nil")
 	}
@@ -276,3 +285,610 @@ func TestRunOnEndHook_FailureReturnsError(t *testing.T) {
 		t.Fatal("expected error from failing hook, got nil")
 	}
 }
+
+// initGitRepo creates a git repository with one commit and returns its path.
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return dir
+}
+
+func TestNewBranch_ExplicitBranch(t *testing.T) {
+	repo := initGitRepo(t)
+	ec := &pipeline.ExecutionContext{Branch: "feat/login", ProfileName: "test"}
+	cfg := &profile.WorktreeConfig{BranchPrefix: "aw/"}
+
+	name, path, err := newBranch(ec, cfg, repo)
+	if err != nil {
+		t.Fatalf("newBranch() error: %v", err)
+	}
+	// The prefix only applies to generated names
+	if name != "feat/login" {
+		t.Errorf("branch = %q, want %q", name, "feat/login")
+	}
+	if want := filepath.Join(repo, "worktrees", "feat-login"); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+}
+
+func TestNewBranch_Template(t *testing.T) {
+	repo := initGitRepo(t)
+	ec := &pipeline.ExecutionContext{Ticket: "PROJ-1", ProfileName: "test"}
+	cfg := &profile.WorktreeConfig{Branch: "{{ticket}}", BranchPrefix: "fix/"}
+
+	name, path, err := newBranch(ec, cfg, repo)
+	if err != nil {
+		t.Fatalf("newBranch() error: %v", err)
+	}
+	if name != "fix/PROJ-1" {
+		t.Errorf("branch = %q, want %q", name, "fix/PROJ-1")
+	}
+	if want := filepath.Join(repo, "worktrees", "fix-PROJ-1"); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+}
+
+func TestNewBranch_Collisions(t *testing.T) {
+	repo := initGitRepo(t)
+	if out, err := exec.Command("git", "-C", repo, "branch", "taken").CombinedOutput(); err != nil {
+		t.Fatalf("git branch: %v\n%s", err, out)
+	}
+	if err := os.MkdirAll(filepath.Join(repo, "worktrees", "dir-taken"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		branch  string
+		tmpl    string
+		wantErr string
+	}{
+		{"explicit existing branch", "taken", "", `branch "taken" already exists`},
+		{"template existing branch", "", "taken", `branch "taken" already exists`},
+		{"existing directory", "dir/taken", "", "worktree path already exists"},
+		{"invalid name", "bad..name", "", "invalid branch name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := &pipeline.ExecutionContext{Branch: tt.branch}
+			_, _, err := newBranch(ec, &profile.WorktreeConfig{Branch: tt.tmpl}, repo)
+			if err == nil {
+				t.Fatal("newBranch() should return error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// setupRemote creates a bare repository acting as "origin" with branches
+// main and feature and a pull request ref refs/pull/7/head, and a clone of
+// it. It returns the bare repository, the clone and the PR head commit.
+func setupRemote(t *testing.T) (string, string, string) {
+	t.Helper()
+	src := initGitRepo(t)
+	git(t, src, "checkout", "-q", "-b", "feature")
+	git(t, src, "commit", "-q", "--allow-empty", "-m", "feature work")
+	git(t, src, "checkout", "-q", "--detach", "main")
+	git(t, src, "commit", "-q", "--allow-empty", "-m", "pull request work")
+	prHead := git(t, src, "rev-parse", "HEAD")
+
+	bare := filepath.Join(t.TempDir(), "remote.git")
+	git(t, src, "init", "-q", "--bare", "-b", "main", bare)
+	git(t, src, "push", "-q", bare, "main", "feature", "HEAD:refs/pull/7/head")
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	git(t, src, "clone", "-q", bare, clone)
+	return bare, clone, prHead
+}
+
+// runWorktreeStage runs WorktreeStage from within repo.
+func runWorktreeStage(t *testing.T, repo string, ec *pipeline.ExecutionContext) {
+	t.Helper()
+	if err := tryWorktreeStage(t, repo, ec); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+}
+
+func tryWorktreeStage(t *testing.T, repo string, ec *pipeline.ExecutionContext) error {
+	t.Helper()
+	return runStageIn(t, repo, &WorktreeStage{}, ec)
+}
+
+// runStageIn runs s from within repo, for tests that use the stage afterwards.
+func runStageIn(t *testing.T, repo string, s *WorktreeStage, ec *pipeline.ExecutionContext) error {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	if ec.Profile.Worktree == nil {
+		ec.Profile = profile.Profile{Worktree: &profile.WorktreeConfig{}, Environment: profile.EnvironmentHost}
+	}
+	ec.HomeDir = t.TempDir()
+	ec.OrigWorkDir = repo
+	return s.Run(context.Background(), ec)
+}
+
+func TestWorktreeStage_ExistingLocalBranch(t *testing.T) {
+	_, clone, _ := setupRemote(t)
+	git(t, clone, "branch", "local-only")
+
+	ec := &pipeline.ExecutionContext{Branch: "local-only"}
+	runWorktreeStage(t, clone, ec)
+
+	if got := git(t, ec.WorktreePath, "rev-parse", "--abbrev-ref", "HEAD"); got != "local-only" {
+		t.Errorf("HEAD = %q, want %q", got, "local-only")
+	}
+	if ec.WorktreeBranch != "local-only" {
+		t.Errorf("WorktreeBranch = %q, want %q", ec.WorktreeBranch, "local-only")
+	}
+}
+
+func TestWorktreeStage_RemoteBranch(t *testing.T) {
+	bare, clone, _ := setupRemote(t)
+	// Pushed after cloning, so it has to be fetched
+	git(t, bare, "branch", "later", "feature")
+
+	for _, branch := range []string{"feature", "later"} {
+		t.Run(branch, func(t *testing.T) {
+			ec := &pipeline.ExecutionContext{Branch: branch}
+			runWorktreeStage(t, clone, ec)
+
+			if got := git(t, ec.WorktreePath, "rev-parse", "--abbrev-ref", "HEAD"); got != branch {
+				t.Errorf("HEAD = %q, want %q", got, branch)
+			}
+			if got := git(t, ec.WorktreePath, "rev-parse", "--abbrev-ref", "@{upstream}"); got != "origin/"+branch {
+				t.Errorf("upstream = %q, want %q", got, "origin/"+branch)
+			}
+			if got, want := git(t, ec.WorktreePath, "rev-parse", "HEAD"), git(t, bare, "rev-parse", branch); got != want {
+				t.Errorf("HEAD commit = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestWorktreeStage_NewBranch(t *testing.T) {
+	_, clone, _ := setupRemote(t)
+
+	ec := &pipeline.ExecutionContext{Branch: "brand-new"}
+	runWorktreeStage(t, clone, ec)
+
+	if got := git(t, ec.WorktreePath, "rev-parse", "--abbrev-ref", "HEAD"); got != "brand-new" {
+		t.Errorf("HEAD = %q, want %q", got, "brand-new")
+	}
+	if got, want := git(t, ec.WorktreePath, "rev-parse", "HEAD"), git(t, clone, "rev-parse", "origin/main"); got != want {
+		t.Errorf("HEAD commit = %s, want origin/main (%s)", got, want)
+	}
+}
+
+func TestWorktreeStage_OnCreateInContainerDeferred(t *testing.T) {
+	_, clone, _ := setupRemote(t)
+
+	ec := &pipeline.ExecutionContext{Profile: profile.Profile{
+		Worktree: &profile.WorktreeConfig{
+			OnCreate:   "touch created",
+			OnCreateIn: profile.HookRunInContainer,
+		},
+		Environment: profile.EnvironmentDocker,
+	}}
+	runWorktreeStage(t, clone, ec)
+
+	if pathExists(filepath.Join(ec.WorktreePath, "created")) {
+		t.Error("on-create should be left to OnCreateStage when it runs in the container")
+	}
+	if !ec.WorktreeNew {
+		t.Error("WorktreeNew = false, want true for a created worktree")
+	}
+}
+
+func TestWorktreeStage_Rollback(t *testing.T) {
+	_, clone, _ := setupRemote(t)
+	git(t, clone, "branch", "local-only")
+
+	tests := []struct {
+		name       string
+		branch     string
+		keepBranch bool
+	}{
+		{"new branch", "brand-new", false},
+		{"remote branch", "feature", false},
+		{"existing local branch", "local-only", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := &pipeline.ExecutionContext{Branch: tt.branch}
+			s := &WorktreeStage{}
+			if err := runStageIn(t, clone, s, ec); err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			path := ec.WorktreePath
+			// Untracked files must not prevent the removal
+			if err := os.WriteFile(filepath.Join(path, "scratch.txt"), []byte("x"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.Rollback(context.Background(), ec); err != nil {
+				t.Fatalf("Rollback() error: %v", err)
+			}
+
+			if pathExists(path) {
+				t.Errorf("worktree %s still exists", path)
+			}
+			if out := git(t, clone, "worktree", "list"); strings.Contains(out, path) {
+				t.Errorf("worktree still registered:\n%s", out)
+			}
+			exists := exec.Command("git", "-C", clone, "show-ref", "--verify", "--quiet", "refs/heads/"+tt.branch).Run() == nil
+			if exists != tt.keepBranch {
+				t.Errorf("branch %s exists = %v, want %v", tt.branch, exists, tt.keepBranch)
+			}
+			if ec.WorktreePath != "" || ec.WorkDir != clone {
+				t.Errorf("WorktreePath = %q, WorkDir = %q; want the original checkout", ec.WorktreePath, ec.WorkDir)
+			}
+		})
+	}
+}
+
+func TestWorktreeStage_RollbackSkipsResumed(t *testing.T) {
+	_, clone, _ := setupRemote(t)
+	ec := &pipeline.ExecutionContext{Branch: "kept"}
+	runWorktreeStage(t, clone, ec)
+	path := ec.WorktreePath
+
+	resumed := &pipeline.ExecutionContext{Resume: true}
+	s := &WorktreeStage{}
+	if err := runStageIn(t, clone, s, resumed); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if err := s.Rollback(context.Background(), resumed); err != nil {
+		t.Fatalf("Rollback() error: %v", err)
+	}
+	if !pathExists(path) {
+		t.Error("a resumed worktree must not be removed")
+	}
+}
+
+func TestWorktreeStage_PullRequest(t *testing.T) {
+	_, clone, prHead := setupRemote(t)
+
+	ec := &pipeline.ExecutionContext{PR: 7}
+	runWorktreeStage(t, clone, ec)
+
+	if ec.WorktreeBranch != "pr-7" {
+		t.Errorf("WorktreeBranch = %q, want %q", ec.WorktreeBranch, "pr-7")
+	}
+	if got := git(t, ec.WorktreePath, "rev-parse", "HEAD"); got != prHead {
+		t.Errorf("HEAD commit = %s, want %s", got, prHead)
+	}
+	if out, err := exec.Command("git", "-C", ec.WorktreePath, "rev-parse", "--abbrev-ref", "@{upstream}").CombinedOutput(); err == nil {
+		t.Errorf("pr-7 should have no upstream, got %s", out)
+	}
+}
+
+func TestWorktreeStage_Resume(t *testing.T) {
+	_, clone, _ := setupRemote(t)
+	marker := filepath.Join(t.TempDir(), "on-create-runs")
+	p := profile.Profile{
+		Worktree:    &profile.WorktreeConfig{OnCreate: "echo run >> " + marker},
+		Environment: profile.EnvironmentHost,
+	}
+
+	created := &pipeline.ExecutionContext{Profile: p, ProfileName: "dev"}
+	runWorktreeStage(t, clone, created)
+
+	resumed := &pipeline.ExecutionContext{Profile: p, ProfileName: "dev", Resume: true}
+	runWorktreeStage(t, clone, resumed)
+
+	if resumed.WorktreePath != created.WorktreePath {
+		t.Errorf("WorktreePath = %q, want %q", resumed.WorktreePath, created.WorktreePath)
+	}
+	if resumed.WorkDir != created.WorktreePath {
+		t.Errorf("WorkDir = %q, want %q", resumed.WorkDir, created.WorktreePath)
+	}
+	if resumed.WorktreeBranch != created.WorktreeBranch {
+		t.Errorf("WorktreeBranch = %q, want %q", resumed.WorktreeBranch, created.WorktreeBranch)
+	}
+	if resumed.RepoRoot != created.RepoRoot {
+		t.Errorf("RepoRoot = %q, want %q", resumed.RepoRoot, created.RepoRoot)
+	}
+
+	data, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs := strings.Count(string(data), "run"); runs != 1 {
+		t.Errorf("on-create ran %d times, want 1", runs)
+	}
+
+	// By name, as used by `aw open`
+	byName := &pipeline.ExecutionContext{Profile: p, ProfileName: "dev", Resume: true, ResumeName: filepath.Base(created.WorktreePath)}
+	runWorktreeStage(t, clone, byName)
+	if byName.WorktreePath != created.WorktreePath {
+		t.Errorf("WorktreePath = %q, want %q", byName.WorktreePath, created.WorktreePath)
+	}
+
+	// Only worktrees created with the same profile are resumed
+	other := &pipeline.ExecutionContext{Profile: p, ProfileName: "other", Resume: true}
+	if err := tryWorktreeStage(t, clone, other); err == nil {
+		t.Error("Run() should fail when the profile has no worktree to resume")
+	}
+}
+
+func TestWorktreeStage_Base(t *testing.T) {
+	_, clone, _ := setupRemote(t)
+	git(t, clone, "branch", "feature/foo", "origin/feature")
+	git(t, clone, "tag", "v1.0", "origin/main")
+	sha := git(t, clone, "rev-parse", "origin/feature")
+
+	tests := []struct {
+		base string
+		want string // rev the worktree should start at
+	}{
+		{"feature/foo", "feature/foo"}, // local branch with a slash is not fetched
+		{"v1.0", "v1.0"},
+		{sha, sha},
+		{"origin/feature", "origin/feature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.base, func(t *testing.T) {
+			ec := &pipeline.ExecutionContext{Profile: profile.Profile{
+				Worktree:    &profile.WorktreeConfig{Base: tt.base},
+				Environment: profile.EnvironmentHost,
+			}}
+			runWorktreeStage(t, clone, ec)
+
+			if got, want := git(t, ec.WorktreePath, "rev-parse", "HEAD"), git(t, clone, "rev-parse", tt.want); got != want {
+				t.Errorf("HEAD commit = %s, want %s (%s)", got, want, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorktreeStage_BaseNotFound(t *testing.T) {
+	_, clone, _ := setupRemote(t)
+
+	ec := &pipeline.ExecutionContext{Profile: profile.Profile{
+		Worktree:    &profile.WorktreeConfig{Base: "no-such-ref"},
+		Environment: profile.EnvironmentHost,
+	}}
+	err := tryWorktreeStage(t, clone, ec)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Run() error = %v, want base not found", err)
+	}
+}
+
+func TestWorktreeStage_FetchModes(t *testing.T) {
+	tests := []struct {
+		name    string
+		fetch   profile.FetchMode
+		base    string
+		offline bool
+		wantNew bool   // worktree starts at the remote's current commit
+		wantErr string // expected error, if any
+	}{
+		{name: "always fetches", fetch: profile.FetchAlways, base: "origin/main", wantNew: true},
+		{name: "always offline uses last fetched", fetch: profile.FetchAlways, base: "origin/main", offline: true},
+		{name: "always offline never fetched", fetch: profile.FetchAlways, base: "origin/later", offline: true, wantErr: "fetching origin/later"},
+		{name: "if-missing uses fetched", fetch: profile.FetchIfMissing, base: "origin/main"},
+		{name: "if-missing fetches missing", fetch: profile.FetchIfMissing, base: "origin/later", wantNew: true},
+		{name: "never uses fetched", fetch: profile.FetchNever, base: "origin/main"},
+		{name: "never with missing ref", fetch: profile.FetchNever, base: "origin/later", wantErr: "has not been fetched"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bare, clone, _ := setupRemote(t)
+			oldMain := git(t, clone, "rev-parse", "origin/main")
+
+			// Advance the remote after cloning
+			git(t, bare, "branch", "later", "feature")
+			src := filepath.Join(t.TempDir(), "src")
+			git(t, bare, "clone", "-q", bare, src)
+			git(t, src, "commit", "-q", "--allow-empty", "-m", "new upstream work")
+			git(t, src, "push", "-q", "origin", "main")
+
+			if tt.offline {
+				git(t, clone, "remote", "set-url", "origin", filepath.Join(t.TempDir(), "unreachable"))
+			}
+
+			ec := &pipeline.ExecutionContext{Profile: profile.Profile{
+				Worktree:    &profile.WorktreeConfig{Base: tt.base, Fetch: tt.fetch},
+				Environment: profile.EnvironmentHost,
+			}}
+			err := tryWorktreeStage(t, clone, ec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Run() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+
+			head := git(t, ec.WorktreePath, "rev-parse", "HEAD")
+			branch := strings.TrimPrefix(tt.base, "origin/")
+			remoteHead := git(t, bare, "rev-parse", branch)
+			switch {
+			case tt.wantNew && head != remoteHead:
+				t.Errorf("HEAD = %s, want the remote's %s %s", head, branch, remoteHead)
+			case !tt.wantNew && branch == "main" && head != oldMain:
+				t.Errorf("HEAD = %s, want the previously fetched main %s", head, oldMain)
+			}
+		})
+	}
+}
+
+func TestWorktreeStage_CopyAndLinkBeforeOnCreate(t *testing.T) {
+	_, clone, _ := setupRemote(t)
+	if err := os.WriteFile(filepath.Join(clone, ".env.local"), []byte("TOKEN=secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(clone, "node_modules", "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ec := &pipeline.ExecutionContext{Profile: profile.Profile{
+		Worktree: &profile.WorktreeConfig{
+			Copy:     []string{".env.local"},
+			Link:     []string{"node_modules"},
+			OnCreate: "test -f .env.local && test -d node_modules/pkg",
+		},
+		Environment: profile.EnvironmentHost,
+	}}
+	runWorktreeStage(t, clone, ec)
+
+	data, err := os.ReadFile(filepath.Join(ec.WorktreePath, ".env.local"))
+	if err != nil || string(data) != "TOKEN=secret\n" {
+		t.Errorf(".env.local = %q, %v", data, err)
+	}
+	if target, err := os.Readlink(filepath.Join(ec.WorktreePath, "node_modules")); err != nil || target != filepath.Join(clone, "node_modules") {
+		t.Errorf("node_modules -> %q, %v; want %q", target, err, filepath.Join(clone, "node_modules"))
+	}
+}
+
+func TestWorktreeStage_Sparse(t *testing.T) {
+	_, clone, _ := setupRemote(t)
+	for _, f := range []string{"packages/api/main.go", "packages/web/index.js", "tools/lint.sh", "README.md"} {
+		path := filepath.Join(clone, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git(t, clone, "add", ".")
+	git(t, clone, "commit", "-q", "-m", "add packages")
+
+	ec := &pipeline.ExecutionContext{Profile: profile.Profile{
+		Worktree: &profile.WorktreeConfig{
+			Base:   "main",
+			Sparse: []string{"packages/api", "tools"},
+		},
+		Environment: profile.EnvironmentHost,
+	}}
+	runWorktreeStage(t, clone, ec)
+
+	for f, want := range map[string]bool{
+		"packages/api/main.go":  true,
+		"tools/lint.sh":         true,
+		"README.md":             true, // files at the root are always included in cone mode
+		"packages/web/index.js": false,
+	} {
+		_, err := os.Stat(filepath.Join(ec.WorktreePath, f))
+		if got := err == nil; got != want {
+			t.Errorf("%s exists = %v, want %v", f, got, want)
+		}
+	}
+	if status := git(t, ec.WorktreePath, "status", "--porcelain"); status != "" {
+		t.Errorf("worktree should be clean, got:\n%s", status)
+	}
+
+	// The main checkout keeps all files
+	if _, err := os.Stat(filepath.Join(clone, "packages", "web", "index.js")); err != nil {
+		t.Errorf("main checkout lost packages/web/index.js: %v", err)
+	}
+}
+
+func TestWorktreeStage_Submodules(t *testing.T) {
+	// Allow file:// submodules (disabled by default since git 2.38.1)
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+
+	nested := initGitRepo(t)
+	if err := os.WriteFile(filepath.Join(nested, "nested.txt"), []byte("nested"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, nested, "add", ".")
+	git(t, nested, "commit", "-q", "-m", "nested")
+
+	sub := initGitRepo(t)
+	git(t, sub, "submodule", "add", "-q", nested, "nested")
+	git(t, sub, "commit", "-q", "-m", "add nested")
+
+	_, clone, _ := setupRemote(t)
+	git(t, clone, "submodule", "add", "-q", sub, "lib")
+	git(t, clone, "commit", "-q", "-m", "add lib")
+
+	for _, tt := range []struct {
+		mode profile.SubmoduleMode
+		want bool
+	}{
+		{profile.SubmodulesRecursive, true},
+		{profile.SubmodulesNone, false},
+	} {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			ec := &pipeline.ExecutionContext{Profile: profile.Profile{
+				Worktree:    &profile.WorktreeConfig{Base: "main", Submodules: tt.mode},
+				Environment: profile.EnvironmentHost,
+			}}
+			runWorktreeStage(t, clone, ec)
+
+			_, err := os.Stat(filepath.Join(ec.WorktreePath, "lib", "nested", "nested.txt"))
+			if got := err == nil; got != tt.want {
+				t.Errorf("lib/nested/nested.txt exists = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorktreeStage_LFSNotInstalled(t *testing.T) {
+	if exec.Command("git", "lfs", "version").Run() == nil {
+		t.Skip("git-lfs is installed")
+	}
+	_, clone, _ := setupRemote(t)
+
+	ec := &pipeline.ExecutionContext{Profile: profile.Profile{
+		Worktree:    &profile.WorktreeConfig{LFS: true},
+		Environment: profile.EnvironmentHost,
+	}}
+	err := tryWorktreeStage(t, clone, ec)
+	if err == nil || !strings.Contains(err.Error(), "git-lfs is not installed") {
+		t.Errorf("Run() error = %v, want git-lfs is not installed", err)
+	}
+}
+
+func TestWorktreeStage_UnreachableRemote(t *testing.T) {
+	_, clone, _ := setupRemote(t)
+	git(t, clone, "remote", "set-url", "origin", filepath.Join(t.TempDir(), "gone"))
+
+	// The branch may exist on the remote: it must not be created from the base
+	ec := &pipeline.ExecutionContext{Branch: "feature-elsewhere"}
+	err := runStageIn(t, clone, &WorktreeStage{}, ec)
+	if err == nil || !strings.Contains(err.Error(), "looking up branch feature-elsewhere on origin") {
+		t.Fatalf("Run() error = %v, want a lookup error", err)
+	}
+	if exec.Command("git", "-C", clone, "show-ref", "--verify", "--quiet", "refs/heads/feature-elsewhere").Run() == nil {
+		t.Error("branch created although the remote could not be asked")
+	}
+}
@@ -0,0 +1,100 @@
+package worktree
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// BranchVars holds the values available to a worktree.branch template.
+type BranchVars struct {
+	User    string    // current user name
+	Ticket  string    // ticket ID given with --ticket
+	Profile string    // profile name
+	Now     time.Time // used for {{date}}
+}
+
+// BranchName expands a worktree.branch template and prepends prefix.
+//
+// The template may contain the placeholders {{user}}, {{date}} (YYYYMMDD),
+// {{words}} (three random words), {{ticket}} and {{profile}}. Using
+// {{ticket}} without a ticket ID is an error.
+func BranchName(tmpl, prefix string, vars BranchVars) (string, error) {
+	var err error
+	expanded := placeholderRe.ReplaceAllStringFunc(tmpl, func(m string) string {
+		if err != nil {
+			return m
+		}
+		switch placeholderRe.FindStringSubmatch(m)[1] {
+		case "user":
+			return vars.User
+		case "date":
+			return vars.Now.Format("20060102")
+		case "words":
+			var words string
+			words, err = GenerateName()
+			return words
+		case "ticket":
+			if vars.Ticket == "" {
+				err = fmt.Errorf("branch template %q uses {{ticket}} but no ticket was given (use --ticket)", tmpl)
+			}
+			return vars.Ticket
+		case "profile":
+			return vars.Profile
+		default:
+			err = fmt.Errorf("unknown placeholder %s in branch template %q (available: {{user}}, {{date}}, {{words}}, {{ticket}}, {{profile}})", m, tmpl)
+			return m
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	if expanded == "" {
+		return "", fmt.Errorf("branch template %q expands to an empty name", tmpl)
+	}
+	return prefix + expanded, nil
+}
+
+// IsRandomTemplate reports whether tmpl contains {{words}}, i.e. whether
+// expanding it again is likely to give a different name.
+func IsRandomTemplate(tmpl string) bool {
+	for _, m := range placeholderRe.FindAllStringSubmatch(tmpl, -1) {
+		if m[1] == "words" {
+			return true
+		}
+	}
+	return false
+}
+
+// DirName returns the name used for the worktree directory and session of
+// the given branch. Slashes are replaced with hyphens so that a branch like
+// "feat/login" does not create nested directories.
+func DirName(branch string) string {
+	return strings.ReplaceAll(branch, "/", "-")
+}
+
+// CurrentUser returns the login name of the current user in a form that
+// can be used in a branch name.
+func CurrentUser() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name = u.Username
+	}
+	// Windows usernames may be qualified as DOMAIN\user
+	if i := strings.LastIndexByte(name, '\\'); i >= 0 {
+		name = name[i+1:]
+	}
+
+	var b strings.Builder
+	for _, c := range strings.ToLower(name) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+			b.WriteRune(c)
+		default:
+			b.WriteByte('-')
+		}
+	}
+	return strings.Trim(b.String(), "-.")
+}
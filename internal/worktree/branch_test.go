@@ -0,0 +1,123 @@
+package worktree
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBranchName(t *testing.T) {
+	vars := BranchVars{
+		User:    "alice",
+		Ticket:  "PROJ-123",
+		Profile: "worktree-zellij",
+		Now:     time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name   string
+		tmpl   string
+		prefix string
+		want   string
+	}{
+		{"user and date", "{{user}}/{{date}}", "", "alice/20260307"},
+		{"ticket", "{{ticket}}", "", "PROJ-123"},
+		{"ticket with prefix", "{{ticket}}-{{user}}", "feat/", "feat/PROJ-123-alice"},
+		{"profile", "aw/{{profile}}", "", "aw/worktree-zellij"},
+		{"spaces in placeholder", "{{ user }}/{{ ticket }}", "", "alice/PROJ-123"},
+		{"literal", "experiment", "", "experiment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BranchName(tt.tmpl, tt.prefix, vars)
+			if err != nil {
+				t.Fatalf("BranchName() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("BranchName(%q, %q) = %q, want %q", tt.tmpl, tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBranchName_Words(t *testing.T) {
+	got, err := BranchName("{{user}}/{{date}}-{{words}}", "aw/", BranchVars{
+		User: "alice",
+		Now:  time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("BranchName() error: %v", err)
+	}
+	re := regexp.MustCompile(`^aw/alice/20260307-[a-z]+-[a-z]+-[a-z]+$`)
+	if !re.MatchString(got) {
+		t.Errorf("BranchName() = %q, want match for %s", got, re)
+	}
+}
+
+func TestBranchName_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		wantErr string
+	}{
+		{"missing ticket", "{{ticket}}-fix", "--ticket"},
+		{"unknown placeholder", "{{branch}}", "unknown placeholder {{branch}}"},
+		{"empty expansion", "{{profile}}", "empty name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BranchName(tt.tmpl, "", BranchVars{})
+			if err == nil {
+				t.Fatal("BranchName() should return error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsRandomTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		want bool
+	}{
+		{"{{words}}", true},
+		{"{{user}}/{{ words }}", true},
+		{"{{ticket}}", false},
+		{"words", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRandomTemplate(tt.tmpl); got != tt.want {
+			t.Errorf("IsRandomTemplate(%q) = %v, want %v", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestDirName(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{"red-fox-jump", "red-fox-jump"},
+		{"feat/login", "feat-login"},
+		{"alice/20260307/fix", "alice-20260307-fix"},
+	}
+
+	for _, tt := range tests {
+		if got := DirName(tt.branch); got != tt.want {
+			t.Errorf("DirName(%q) = %q, want %q", tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestCurrentUser(t *testing.T) {
+	got := CurrentUser()
+	if !regexp.MustCompile(`^[a-z0-9._-]*$`).MatchString(got) {
+		t.Errorf("CurrentUser() = %q, want only lowercase letters, digits, '.', '_' and '-'", got)
+	}
+}
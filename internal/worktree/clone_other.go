@@ -0,0 +1,13 @@
+//go:build !linux || !(amd64 || arm64)
+
+package worktree
+
+import (
+	"errors"
+	"os"
+)
+
+// cloneFile is not supported on this platform; callers fall back to copying.
+func cloneFile(dst, src *os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,119 @@
+package worktree
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CopyFiles copies the files and directories in src matching patterns into
+// the same relative location in dst. Patterns are relative to src and use
+// filepath.Match syntax. Destinations that already exist (e.g. tracked
+// files) are left alone. Files are cloned (copy-on-write) when the
+// filesystem supports it. It returns the relative paths that were copied.
+func CopyFiles(src, dst string, patterns []string) ([]string, error) {
+	return forEachMatch(src, dst, patterns, copyPath)
+}
+
+// LinkFiles creates symlinks in dst pointing to the files and directories in
+// src matching patterns, following the same rules as CopyFiles.
+func LinkFiles(src, dst string, patterns []string) ([]string, error) {
+	return forEachMatch(src, dst, patterns, os.Symlink)
+}
+
+func forEachMatch(src, dst string, patterns []string, fn func(from, to string) error) ([]string, error) {
+	var done []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(src, pattern))
+		if err != nil {
+			return done, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		for _, from := range matches {
+			// The directory holding the worktree would be copied into
+			// itself
+			if within(dst, from) {
+				continue
+			}
+			rel, err := filepath.Rel(src, from)
+			if err != nil {
+				return done, err
+			}
+			to := filepath.Join(dst, rel)
+			if _, err := os.Lstat(to); err == nil {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+				return done, err
+			}
+			if err := fn(from, to); err != nil {
+				return done, fmt.Errorf("%s: %w", rel, err)
+			}
+			done = append(done, rel)
+		}
+	}
+	return done, nil
+}
+
+// within reports whether path is dir or inside it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// copyPath copies a file, directory (recursively) or symlink.
+func copyPath(from, to string) error {
+	info, err := os.Lstat(from)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(from)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, to)
+	case info.IsDir():
+		if err := os.Mkdir(to, info.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(from)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := copyPath(filepath.Join(from, e.Name()), filepath.Join(to, e.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	case info.Mode().IsRegular():
+		return copyFile(from, to, info.Mode().Perm())
+	default:
+		return nil // sockets, devices, etc. are skipped
+	}
+}
+
+func copyFile(from, to string, perm os.FileMode) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	if cloneFile(out, in) != nil {
+		if _, err := io.Copy(out, in); err != nil {
+			_ = out.Close()
+			return err
+		}
+	}
+	return out.Close()
+}
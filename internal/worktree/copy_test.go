@@ -0,0 +1,126 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string, perm os.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCopyFiles(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	writeFile(t, filepath.Join(src, ".env.local"), "TOKEN=secret", 0600)
+	writeFile(t, filepath.Join(src, "config", "db.local.yml"), "db: local", 0644)
+	writeFile(t, filepath.Join(src, "config", "app.local.yml"), "app: local", 0644)
+	writeFile(t, filepath.Join(src, "config", "app.yml"), "app: shared", 0644)
+	writeFile(t, filepath.Join(src, "node_modules", "pkg", "index.js"), "module.exports = 1", 0644)
+	writeFile(t, filepath.Join(src, "node_modules", ".bin", "tool"), "#!/bin/sh", 0755)
+	if err := os.Symlink("pkg/index.js", filepath.Join(src, "node_modules", "main.js")); err != nil {
+		t.Fatal(err)
+	}
+	// Already present in the worktree (e.g. tracked), must not be overwritten
+	writeFile(t, filepath.Join(dst, "config", "db.local.yml"), "db: tracked", 0644)
+
+	copied, err := CopyFiles(src, dst, []string{".env.local", "config/*.local.yml", "node_modules", "missing.txt"})
+	if err != nil {
+		t.Fatalf("CopyFiles() error: %v", err)
+	}
+
+	sort.Strings(copied)
+	want := []string{".env.local", filepath.Join("config", "app.local.yml"), "node_modules"}
+	if !reflect.DeepEqual(copied, want) {
+		t.Errorf("CopyFiles() = %v, want %v", copied, want)
+	}
+
+	if got := readFile(t, filepath.Join(dst, ".env.local")); got != "TOKEN=secret" {
+		t.Errorf(".env.local = %q, want %q", got, "TOKEN=secret")
+	}
+	if info, err := os.Stat(filepath.Join(dst, ".env.local")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf(".env.local mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+	if got := readFile(t, filepath.Join(dst, "config", "db.local.yml")); got != "db: tracked" {
+		t.Errorf("existing file was overwritten: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "config", "app.yml")); err == nil {
+		t.Error("config/app.yml should not be copied")
+	}
+	if got := readFile(t, filepath.Join(dst, "node_modules", "pkg", "index.js")); got != "module.exports = 1" {
+		t.Errorf("node_modules/pkg/index.js = %q", got)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "node_modules", ".bin", "tool")); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("node_modules/.bin/tool should stay executable: %v, %v", info.Mode(), err)
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "node_modules", "main.js")); err != nil || target != "pkg/index.js" {
+		t.Errorf("node_modules/main.js symlink = %q, %v; want pkg/index.js", target, err)
+	}
+}
+
+func TestLinkFiles(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	writeFile(t, filepath.Join(src, "node_modules", "pkg", "index.js"), "module.exports = 1", 0644)
+	writeFile(t, filepath.Join(src, ".cache", "build"), "cached", 0644)
+
+	linked, err := LinkFiles(src, dst, []string{"node_modules", ".cache/*"})
+	if err != nil {
+		t.Fatalf("LinkFiles() error: %v", err)
+	}
+	sort.Strings(linked)
+	want := []string{filepath.Join(".cache", "build"), "node_modules"}
+	if !reflect.DeepEqual(linked, want) {
+		t.Errorf("LinkFiles() = %v, want %v", linked, want)
+	}
+
+	target, err := os.Readlink(filepath.Join(dst, "node_modules"))
+	if err != nil {
+		t.Fatalf("node_modules should be a symlink: %v", err)
+	}
+	if target != filepath.Join(src, "node_modules") {
+		t.Errorf("node_modules -> %q, want %q", target, filepath.Join(src, "node_modules"))
+	}
+	if got := readFile(t, filepath.Join(dst, ".cache", "build")); got != "cached" {
+		t.Errorf(".cache/build = %q, want %q", got, "cached")
+	}
+}
+
+func TestCopyFiles_SkipsWorktreeDir(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(src, "worktrees", "feat")
+	writeFile(t, filepath.Join(src, ".env.local"), "TOKEN=secret", 0600)
+	writeFile(t, filepath.Join(dst, "README.md"), "tracked", 0644)
+
+	copied, err := CopyFiles(src, dst, []string{"*", ".*"})
+	if err != nil {
+		t.Fatalf("CopyFiles() error: %v", err)
+	}
+	if want := []string{".env.local"}; !reflect.DeepEqual(copied, want) {
+		t.Errorf("CopyFiles() = %v, want %v", copied, want)
+	}
+}
+
+func TestCopyFiles_InvalidPattern(t *testing.T) {
+	if _, err := CopyFiles(t.TempDir(), t.TempDir(), []string{"[a-"}); err == nil {
+		t.Error("CopyFiles() should return error for invalid pattern")
+	}
+}
@@ -3,31 +3,44 @@ package worktree
 import (
 	"fmt"
 	"os/exec"
+	"runtime"
+	"slices"
 	"strings"
 )
 
-// requiredDeps are commands that must be present for --worktree to work.
-var requiredDeps = []string{"git", "zellij"}
+// requiredDeps are commands that must be present for worktrees to work.
+var requiredDeps = []string{"git"}
 
-// optionalDep describes an optional command with an install hint.
-type optionalDep struct {
-	name string
-	hint string
+// Dependency describes an optional command with install hints.
+type Dependency struct {
+	Name      string
+	MacOSHint string // install command on macOS
+	LinuxHint string // install command on Linux (Debian/Ubuntu)
 }
 
-// optionalDeps are commands used by helper scripts, with install hints.
-var optionalDeps = []optionalDep{
-	{"fswatch", "brew install fswatch"},
-	{"fzf", "brew install fzf"},
-	{"delta", "brew install git-delta"},
-	{"glow", "brew install glow"},
-	{"gh", "brew install gh"},
+// InstallHint returns the install hint for goos (a runtime.GOOS value).
+func (d Dependency) InstallHint(goos string) string {
+	if goos == "darwin" {
+		return d.MacOSHint
+	}
+	return d.LinuxHint
+}
+
+// OptionalDeps are commands used by helper scripts, with install hints.
+var OptionalDeps = []Dependency{
+	{"fswatch", "brew install fswatch", "sudo apt install fswatch"},
+	{"fzf", "brew install fzf", "sudo apt install fzf"},
+	{"delta", "brew install git-delta", "sudo apt install git-delta"},
+	{"glow", "brew install glow", "go install github.com/charmbracelet/glow@latest"},
+	{"gh", "brew install gh", "sudo apt install gh"},
 }
 
-// CheckRequiredDeps verifies that all required external commands are available.
-func CheckRequiredDeps() error {
+// CheckRequiredDeps verifies that all required external commands are
+// available, together with extra ones the profile needs (e.g. its terminal
+// multiplexer).
+func CheckRequiredDeps(extra ...string) error {
 	var missing []string
-	for _, cmd := range requiredDeps {
+	for _, cmd := range append(slices.Clone(requiredDeps), extra...) {
 		if _, err := exec.LookPath(cmd); err != nil {
 			missing = append(missing, cmd)
 		}
@@ -41,9 +54,9 @@ func CheckRequiredDeps() error {
 // CheckOptionalDeps checks optional commands and returns warnings for missing ones.
 func CheckOptionalDeps() []string {
 	var warnings []string
-	for _, dep := range optionalDeps {
-		if _, err := exec.LookPath(dep.name); err != nil {
-			warnings = append(warnings, fmt.Sprintf("  %s not found (install: %s)", dep.name, dep.hint))
+	for _, dep := range OptionalDeps {
+		if _, err := exec.LookPath(dep.Name); err != nil {
+			warnings = append(warnings, fmt.Sprintf("  %s not found (install: %s)", dep.Name, dep.InstallHint(runtime.GOOS)))
 		}
 	}
 	return warnings
@@ -1,14 +1,20 @@
 package worktree
 
 import (
+	"strings"
 	"testing"
 )
 
 func TestCheckRequiredDeps(t *testing.T) {
 	err := CheckRequiredDeps()
-	// We don't fail the test if zellij is missing, just check the function runs
+	// We don't fail the test if git is missing, just check the function runs
 	if err != nil {
-		t.Logf("CheckRequiredDeps returned error (expected if zellij not installed): %v", err)
+		t.Logf("CheckRequiredDeps returned error (expected if git not installed): %v", err)
+	}
+
+	err = CheckRequiredDeps("aw-no-such-command")
+	if err == nil || !strings.Contains(err.Error(), "aw-no-such-command") {
+		t.Errorf("CheckRequiredDeps() = %v, want the missing command reported", err)
 	}
 }
 
@@ -0,0 +1,174 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hiragram/agent-workspace/internal/proc"
+)
+
+// CheckBranchName verifies that name is a valid git branch name.
+func CheckBranchName(name string) error {
+	if err := exec.Command("git", "check-ref-format", "--branch", name).Run(); err != nil {
+		return fmt.Errorf("invalid branch name %q", name)
+	}
+	return nil
+}
+
+// BranchExists reports whether a local branch or a remote-tracking branch
+// (on any remote) with the given name exists in the repository.
+func BranchExists(repoRoot, name string) (bool, error) {
+	if RefExists(repoRoot, "refs/heads/"+name) {
+		return true, nil
+	}
+	remotes, err := Remotes(repoRoot)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range remotes {
+		if RefExists(repoRoot, "refs/remotes/"+r+"/"+name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RefExists reports whether the fully qualified ref exists.
+func RefExists(repoRoot, ref string) bool {
+	return exec.Command("git", "-C", repoRoot, "show-ref", "--verify", "--quiet", ref).Run() == nil
+}
+
+// Remotes returns the names of the configured remotes.
+func Remotes(repoRoot string) ([]string, error) {
+	out, err := exec.Command("git", "-C", repoRoot, "remote").Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing remotes: %w", err)
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// DefaultRemote returns "origin" if it is one of remotes, and the first
+// remote otherwise. remotes must not be empty.
+func DefaultRemote(remotes []string) string {
+	for _, r := range remotes {
+		if r == "origin" {
+			return r
+		}
+	}
+	return remotes[0]
+}
+
+// Base is a worktree base ref resolved against the repository.
+type Base struct {
+	Ref    string // the base as configured
+	Remote string // remote of a remote-tracking branch; empty for local refs and commits
+	Branch string // branch name on Remote
+}
+
+// TrackingRef returns the fully qualified remote-tracking ref of a remote base.
+func (b Base) TrackingRef() string {
+	return "refs/remotes/" + b.Remote + "/" + b.Branch
+}
+
+// ResolveBase determines whether base names a branch on one of the
+// repository's remotes (e.g. "origin/main") or something local: a local
+// branch (which may contain slashes, e.g. "feature/foo"), a tag or a commit.
+// Like git, local branches and tags take precedence over remote branches.
+func ResolveBase(repoRoot, base string) (Base, error) {
+	if RefExists(repoRoot, "refs/heads/"+base) || RefExists(repoRoot, "refs/tags/"+base) {
+		return Base{Ref: base}, nil
+	}
+
+	remotes, err := Remotes(repoRoot)
+	if err != nil {
+		return Base{}, err
+	}
+	name := strings.TrimPrefix(base, "refs/remotes/")
+	remote := ""
+	for _, r := range remotes {
+		// Prefer the longest match, since remote names may contain slashes
+		if strings.HasPrefix(name, r+"/") && len(r) > len(remote) {
+			remote = r
+		}
+	}
+	if remote == "" {
+		return Base{Ref: base}, nil
+	}
+	return Base{Ref: base, Remote: remote, Branch: strings.TrimPrefix(name, remote+"/")}, nil
+}
+
+// CommitExists reports whether rev resolves to a commit.
+func CommitExists(repoRoot, rev string) bool {
+	return exec.Command("git", "-C", repoRoot, "rev-parse", "--verify", "--quiet", rev+"^{commit}").Run() == nil
+}
+
+// DefaultBase returns the base for new worktrees when none is configured:
+// the default branch of the default remote (e.g. "origin/develop"), as
+// recorded in refs/remotes/<remote>/HEAD. If that ref is not set, the remote
+// is asked for its HEAD and the answer is recorded for next time. If the
+// remote cannot be reached, "<remote>/main" is returned. A repository
+// without remotes uses its current branch.
+func DefaultBase(ctx context.Context, repoRoot string) string {
+	if base := LocalDefaultBase(repoRoot); base != "" {
+		return base
+	}
+	remotes, err := Remotes(repoRoot)
+	if err != nil || len(remotes) == 0 {
+		return "main"
+	}
+	remote := DefaultRemote(remotes)
+
+	branch := remoteHead(ctx, repoRoot, remote)
+	if branch == "" {
+		return remote + "/main"
+	}
+	// Record it like `git clone` does; failing to is harmless
+	_ = exec.Command("git", "-C", repoRoot, "symbolic-ref", "refs/remotes/"+remote+"/HEAD", "refs/remotes/"+remote+"/"+branch).Run()
+	return remote + "/" + branch
+}
+
+// LocalDefaultBase returns what DefaultBase would without asking a remote,
+// which can be slow or prompt for credentials: the recorded default branch
+// of the default remote, or the current branch without remotes. It returns
+// "" if only the remote knows.
+func LocalDefaultBase(repoRoot string) string {
+	remotes, err := Remotes(repoRoot)
+	if err != nil || len(remotes) == 0 {
+		out, err := exec.Command("git", "-C", repoRoot, "symbolic-ref", "--quiet", "--short", "HEAD").Output()
+		if branch := strings.TrimSpace(string(out)); err == nil && branch != "" {
+			return branch
+		}
+		return ""
+	}
+	headRef := "refs/remotes/" + DefaultRemote(remotes) + "/HEAD"
+	out, err := exec.Command("git", "-C", repoRoot, "symbolic-ref", "--quiet", "--short", headRef).Output()
+	if base := strings.TrimSpace(string(out)); err == nil && base != "" {
+		return base
+	}
+	return ""
+}
+
+// remoteHead asks remote for the branch its HEAD points to, returning ""
+// if it cannot be reached.
+func remoteHead(ctx context.Context, repoRoot, remote string) string {
+	cmd := proc.Interruptible(exec.CommandContext(ctx, "git", "-C", repoRoot, "ls-remote", "--symref", remote, "HEAD"))
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	// ref: refs/heads/main	HEAD
+	for _, line := range strings.Split(string(out), "\n") {
+		ref, ok := strings.CutPrefix(line, "ref: refs/heads/")
+		if !ok {
+			continue
+		}
+		if branch, _, ok := strings.Cut(ref, "\tHEAD"); ok {
+			return branch
+		}
+	}
+	return ""
+}
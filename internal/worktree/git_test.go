@@ -0,0 +1,188 @@
+package worktree
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// initRepo creates a git repository with one commit and returns its path.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	// Resolve symlinks (e.g. /var -> /private/var on macOS) so that paths
+	// compare equal to the ones reported by git
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "init", "-q", "-b", "main")
+	runGit(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com",
+		"commit", "-q", "--allow-empty", "-m", "initial")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestCheckBranchName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"red-fox-jump", false},
+		{"feat/login", false},
+		{"PROJ-123", false},
+		{"has space", true},
+		{"double..dot", true},
+		{"trailing/", true},
+		{"-leading-dash", true},
+	}
+
+	for _, tt := range tests {
+		err := CheckBranchName(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckBranchName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBranchExists(t *testing.T) {
+	remote := initRepo(t)
+	runGit(t, remote, "branch", "remote-only")
+
+	repo := filepath.Join(t.TempDir(), "clone")
+	if out, err := exec.Command("git", "clone", "-q", remote, repo).CombinedOutput(); err != nil {
+		t.Fatalf("git clone: %v\n%s", err, out)
+	}
+	runGit(t, repo, "branch", "feat/local")
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"main", true},
+		{"feat/local", true},
+		{"remote-only", true},
+		{"feat", false},
+		{"local", false},
+		{"missing", false},
+	}
+
+	for _, tt := range tests {
+		got, err := BranchExists(repo, tt.name)
+		if err != nil {
+			t.Fatalf("BranchExists(%q) error: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("BranchExists(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveBase(t *testing.T) {
+	repo := initRepo(t)
+	runGit(t, repo, "remote", "add", "origin", "https://example.com/repo.git")
+	runGit(t, repo, "remote", "add", "me/fork", "https://example.com/fork.git")
+	runGit(t, repo, "branch", "feature/foo")
+	runGit(t, repo, "branch", "origin/shadow")
+	runGit(t, repo, "tag", "v1.0")
+
+	tests := []struct {
+		base       string
+		wantRemote string
+		wantBranch string
+	}{
+		{"origin/main", "origin", "main"},
+		{"origin/release/2.0", "origin", "release/2.0"},
+		{"refs/remotes/origin/main", "origin", "main"},
+		{"me/fork/main", "me/fork", "main"},
+		{"feature/foo", "", ""},
+		{"origin/shadow", "", ""}, // local branch wins, like in git
+		{"v1.0", "", ""},
+		{"abc123", "", ""},
+		{"main", "", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := ResolveBase(repo, tt.base)
+		if err != nil {
+			t.Fatalf("ResolveBase(%q) error: %v", tt.base, err)
+		}
+		if got.Ref != tt.base || got.Remote != tt.wantRemote || got.Branch != tt.wantBranch {
+			t.Errorf("ResolveBase(%q) = %+v, want remote %q, branch %q", tt.base, got, tt.wantRemote, tt.wantBranch)
+		}
+	}
+}
+
+func TestCommitExists(t *testing.T) {
+	repo := initRepo(t)
+	runGit(t, repo, "tag", "v1.0")
+
+	for rev, want := range map[string]bool{
+		"main":    true,
+		"v1.0":    true,
+		"HEAD~0":  true,
+		"missing": false,
+	} {
+		if got := CommitExists(repo, rev); got != want {
+			t.Errorf("CommitExists(%q) = %v, want %v", rev, got, want)
+		}
+	}
+}
+
+func TestDefaultBase(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("no remotes uses the current branch", func(t *testing.T) {
+		repo := initRepo(t)
+		runGit(t, repo, "checkout", "-q", "-b", "trunk")
+		if got := DefaultBase(ctx, repo); got != "trunk" {
+			t.Errorf("DefaultBase() = %q, want trunk", got)
+		}
+	})
+
+	remote := initRepo(t)
+	runGit(t, remote, "checkout", "-q", "-b", "develop")
+	repo := filepath.Join(t.TempDir(), "clone")
+	if out, err := exec.Command("git", "clone", "-q", remote, repo).CombinedOutput(); err != nil {
+		t.Fatalf("git clone: %v\n%s", err, out)
+	}
+
+	t.Run("remote HEAD", func(t *testing.T) {
+		if got := DefaultBase(ctx, repo); got != "origin/develop" {
+			t.Errorf("DefaultBase() = %q, want origin/develop", got)
+		}
+		if got := LocalDefaultBase(repo); got != "origin/develop" {
+			t.Errorf("LocalDefaultBase() = %q, want origin/develop", got)
+		}
+	})
+
+	t.Run("asks the remote and records its HEAD", func(t *testing.T) {
+		runGit(t, repo, "remote", "set-head", "origin", "--delete")
+		if got := LocalDefaultBase(repo); got != "" {
+			t.Errorf("LocalDefaultBase() = %q, want none without asking the remote", got)
+		}
+		if got := DefaultBase(ctx, repo); got != "origin/develop" {
+			t.Errorf("DefaultBase() = %q, want origin/develop", got)
+		}
+		out, err := exec.Command("git", "-C", repo, "symbolic-ref", "--short", "refs/remotes/origin/HEAD").Output()
+		if err != nil || strings.TrimSpace(string(out)) != "origin/develop" {
+			t.Errorf("origin/HEAD = %q (%v), want it recorded", out, err)
+		}
+	})
+
+	t.Run("unreachable remote", func(t *testing.T) {
+		repo := initRepo(t)
+		runGit(t, repo, "remote", "add", "upstream", filepath.Join(t.TempDir(), "missing"))
+		if got := DefaultBase(ctx, repo); got != "upstream/main" {
+			t.Errorf("DefaultBase() = %q, want upstream/main", got)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package worktree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// metadataFile is the name of the file, in the worktree's administrative
+// directory (<repo>/.git/worktrees/<id>/), that marks aw-created worktrees.
+const metadataFile = "aw.json"
+
+// Metadata describes an aw-created worktree.
+type Metadata struct {
+	Profile    string    `json:"profile"`
+	Branch     string    `json:"branch"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+
+	// RepoRoot is the root of the main working tree (required). git cannot
+	// always tell it from a linked worktree, e.g. with --separate-git-dir.
+	RepoRoot string `json:"repo_root"`
+}
+
+// Info is an aw-created worktree found in a repository.
+type Info struct {
+	Metadata
+	Path string // worktree path
+}
+
+// Name returns the name used by `aw open`, i.e. the worktree directory name.
+func (i Info) Name() string {
+	return filepath.Base(i.Path)
+}
+
+// WriteMetadata records m for the worktree at worktreePath.
+func WriteMetadata(worktreePath string, m Metadata) error {
+	if m.RepoRoot == "" {
+		return fmt.Errorf("writing worktree metadata: no repository root")
+	}
+	out, err := exec.Command("git", "-C", worktreePath, "rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		return fmt.Errorf("finding git dir of %s: %w", worktreePath, err)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(strings.TrimSpace(string(out)), metadataFile)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing worktree metadata: %w", err)
+	}
+	return nil
+}
+
+// List returns the aw-created worktrees of the repository containing dir,
+// most recently used first. Worktrees whose directory no longer exists are
+// skipped.
+func List(dir string) ([]Info, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--path-format=absolute", "--git-common-dir").Output()
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository")
+	}
+	commonDir := strings.TrimSpace(string(out))
+
+	adminDirs, err := filepath.Glob(filepath.Join(commonDir, "worktrees", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []Info
+	for _, adminDir := range adminDirs {
+		data, err := os.ReadFile(filepath.Join(adminDir, metadataFile))
+		if err != nil {
+			continue // not created by aw
+		}
+		var m Metadata
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", filepath.Join(adminDir, metadataFile), err)
+		}
+		if m.RepoRoot == "" {
+			return nil, fmt.Errorf("reading %s: repo_root is missing", filepath.Join(adminDir, metadataFile))
+		}
+
+		// The gitdir file holds the path of the worktree's .git file
+		gitdir, err := os.ReadFile(filepath.Join(adminDir, "gitdir"))
+		if err != nil {
+			continue
+		}
+		path := filepath.Dir(strings.TrimSpace(string(gitdir)))
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		infos = append(infos, Info{Metadata: m, Path: path})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].LastUsedAt.After(infos[j].LastUsedAt)
+	})
+	return infos, nil
+}
+
+// ErrNotFound is returned by Find when no matching worktree exists.
+var ErrNotFound = errors.New("worktree not found")
+
+// Find returns the aw-created worktree of the repository containing dir
+// whose directory name or branch is name.
+func Find(dir, name string) (Info, error) {
+	infos, err := List(dir)
+	if err != nil {
+		return Info{}, err
+	}
+	for _, info := range infos {
+		if info.Name() == name || info.Branch == name {
+			return info, nil
+		}
+	}
+	return Info{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
@@ -0,0 +1,120 @@
+package worktree
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func addWorktree(t *testing.T, repo, branch string) string {
+	t.Helper()
+	path := filepath.Join(repo, "worktrees", DirName(branch))
+	runGit(t, repo, "worktree", "add", "-q", "-b", branch, path)
+	return path
+}
+
+func TestList(t *testing.T) {
+	repo := initRepo(t)
+	older := addWorktree(t, repo, "older")
+	newer := addWorktree(t, repo, "feat/newer")
+	addWorktree(t, repo, "manual") // not created by aw
+	removed := addWorktree(t, repo, "removed")
+
+	now := time.Now()
+	for path, m := range map[string]Metadata{
+		older:   {Profile: "dev", Branch: "older", LastUsedAt: now.Add(-time.Hour), RepoRoot: repo},
+		newer:   {Profile: "review", Branch: "feat/newer", LastUsedAt: now, RepoRoot: repo},
+		removed: {Profile: "dev", Branch: "removed", LastUsedAt: now, RepoRoot: repo},
+	} {
+		if err := WriteMetadata(path, m); err != nil {
+			t.Fatalf("WriteMetadata(%s) error: %v", path, err)
+		}
+	}
+	if err := os.RemoveAll(removed); err != nil {
+		t.Fatal(err)
+	}
+
+	// Works from the main checkout and from within a worktree
+	for _, dir := range []string{repo, older} {
+		infos, err := List(dir)
+		if err != nil {
+			t.Fatalf("List(%s) error: %v", dir, err)
+		}
+		if len(infos) != 2 {
+			t.Fatalf("List(%s) returned %d worktrees, want 2: %+v", dir, len(infos), infos)
+		}
+		if infos[0].Path != newer || infos[1].Path != older {
+			t.Errorf("List(%s) paths = [%s %s], want [%s %s] (most recently used first)", dir, infos[0].Path, infos[1].Path, newer, older)
+		}
+		if infos[0].Profile != "review" || infos[0].Name() != "feat-newer" {
+			t.Errorf("infos[0] = %+v, want profile review, name feat-newer", infos[0])
+		}
+		if infos[0].RepoRoot != repo {
+			t.Errorf("RepoRoot = %q, want %q", infos[0].RepoRoot, repo)
+		}
+	}
+}
+
+func TestList_SeparateGitDir(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(dir, "repo")
+	runGit(t, dir, "init", "-q", "--separate-git-dir", filepath.Join(dir, "repo.git"), repo)
+	runGit(t, repo, "-c", "user.name=test", "-c", "user.email=test@example.com",
+		"commit", "-q", "--allow-empty", "-m", "initial")
+	path := addWorktree(t, repo, "feat")
+	if err := WriteMetadata(path, Metadata{Profile: "dev", Branch: "feat", RepoRoot: repo}); err != nil {
+		t.Fatalf("WriteMetadata() error: %v", err)
+	}
+
+	infos, err := List(path)
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(infos) != 1 || infos[0].RepoRoot != repo {
+		t.Errorf("List() = %+v, want RepoRoot %s", infos, repo)
+	}
+}
+
+func TestMetadata_RepoRootRequired(t *testing.T) {
+	repo := initRepo(t)
+	path := addWorktree(t, repo, "feat")
+	if err := WriteMetadata(path, Metadata{Profile: "dev", Branch: "feat"}); err == nil {
+		t.Error("WriteMetadata() without RepoRoot succeeded")
+	}
+
+	gitDir := filepath.Join(repo, ".git", "worktrees", "feat")
+	if err := os.WriteFile(filepath.Join(gitDir, metadataFile), []byte(`{"profile": "dev", "branch": "feat"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := List(repo); err == nil || !strings.Contains(err.Error(), "repo_root") {
+		t.Errorf("List() error = %v, want a missing repo_root error", err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	repo := initRepo(t)
+	path := addWorktree(t, repo, "feat/login")
+	if err := WriteMetadata(path, Metadata{Profile: "dev", Branch: "feat/login", RepoRoot: repo}); err != nil {
+		t.Fatalf("WriteMetadata() error: %v", err)
+	}
+
+	for _, name := range []string{"feat-login", "feat/login"} {
+		info, err := Find(repo, name)
+		if err != nil {
+			t.Fatalf("Find(%q) error: %v", name, err)
+		}
+		if info.Path != path {
+			t.Errorf("Find(%q).Path = %q, want %q", name, info.Path, path)
+		}
+	}
+
+	if _, err := Find(repo, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Find(missing) error = %v, want ErrNotFound", err)
+	}
+}
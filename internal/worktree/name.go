@@ -2,45 +2,94 @@ package worktree
 
 import (
 	"bufio"
+	_ "embed"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"strings"
 )
 
 const (
-	dictPath   = "/usr/share/dict/words"
 	maxWordLen = 6
 	wordCount  = 3
 )
 
+// dictPath is the system dictionary. It is a package-level var for testing.
+var dictPath = "/usr/share/dict/words"
+
+// DictPath returns the path of the system dictionary used for random
+// branch names.
+func DictPath() string {
+	return dictPath
+}
+
+// fallbackWords is used when the system dictionary is missing, which is
+// common on minimal Linux installs and in containers.
+//
+//go:embed embed/words.txt
+var fallbackWords string
+
 // GenerateName creates a random branch name by picking 3 short words
-// from the system dictionary, joined by hyphens.
+// from the system dictionary (or the embedded word list if it is not
+// available), joined by hyphens.
 func GenerateName() (string, error) {
+	words, err := loadWords()
+	if err != nil {
+		return "", err
+	}
+	if len(words) < wordCount {
+		return "", fmt.Errorf("not enough words in dictionary (found %d, need %d)", len(words), wordCount)
+	}
+
+	picked := make([]string, wordCount)
+	for i := 0; i < wordCount; i++ {
+		picked[i] = words[rand.Intn(len(words))]
+	}
+	return strings.Join(picked, "-"), nil
+}
+
+// loadWords returns the candidate words from the system dictionary, falling
+// back to the embedded word list if the dictionary cannot be opened or has
+// too few usable words.
+func loadWords() ([]string, error) {
 	f, err := os.Open(dictPath)
 	if err != nil {
-		return "", fmt.Errorf("opening dictionary %s: %w", dictPath, err)
+		return readWords(strings.NewReader(fallbackWords))
 	}
 	defer func() { _ = f.Close() }()
 
+	words, err := readWords(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading dictionary: %w", err)
+	}
+	if len(words) < wordCount {
+		return readWords(strings.NewReader(fallbackWords))
+	}
+	return words, nil
+}
+
+// readWords returns the short, purely alphabetic words from r, lowercased.
+func readWords(r io.Reader) ([]string, error) {
 	var words []string
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		w := strings.TrimSpace(scanner.Text())
-		if len(w) > 0 && len(w) <= maxWordLen {
-			words = append(words, strings.ToLower(w))
+		w := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if len(w) > 0 && len(w) <= maxWordLen && isAlpha(w) {
+			words = append(words, w)
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("reading dictionary: %w", err)
-	}
-	if len(words) < wordCount {
-		return "", fmt.Errorf("not enough words in dictionary (found %d, need %d)", len(words), wordCount)
+		return nil, err
 	}
+	return words, nil
+}
 
-	picked := make([]string, wordCount)
-	for i := 0; i < wordCount; i++ {
-		picked[i] = words[rand.Intn(len(words))]
+func isAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'a' || s[i] > 'z' {
+			return false
+		}
 	}
-	return strings.Join(picked, "-"), nil
+	return true
 }
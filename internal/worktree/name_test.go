@@ -8,7 +8,7 @@ import (
 func TestGenerateName(t *testing.T) {
 	name, err := GenerateName()
 	if err != nil {
-		t.Skipf("Skipping on systems without %s: %v", dictPath, err)
+		t.Fatalf("GenerateName() error: %v", err)
 	}
 	parts := strings.Split(name, "-")
 	if len(parts) != 3 {
@@ -28,9 +28,41 @@ func TestGenerateName_Uniqueness(t *testing.T) {
 	name1, err1 := GenerateName()
 	name2, err2 := GenerateName()
 	if err1 != nil || err2 != nil {
-		t.Skipf("Skipping on systems without dictionary")
+		t.Fatalf("GenerateName() error: %v, %v", err1, err2)
 	}
 	if name1 == name2 {
 		t.Logf("Warning: same name generated twice: %q (statistically unlikely but possible)", name1)
 	}
 }
+
+func TestGenerateName_FallbackWordList(t *testing.T) {
+	orig := dictPath
+	dictPath = "/nonexistent/dict/words"
+	defer func() { dictPath = orig }()
+
+	name, err := GenerateName()
+	if err != nil {
+		t.Fatalf("GenerateName() error: %v", err)
+	}
+
+	embedded := make(map[string]bool)
+	for _, w := range strings.Fields(fallbackWords) {
+		embedded[w] = true
+	}
+	for _, w := range strings.Split(name, "-") {
+		if !embedded[w] {
+			t.Errorf("word %q from %q is not in the embedded word list", w, name)
+		}
+	}
+}
+
+func TestReadWords_SkipsUnusableWords(t *testing.T) {
+	words, err := readWords(strings.NewReader("Apple\ncan't\nbanana\nextraordinary\n\nfig\n"))
+	if err != nil {
+		t.Fatalf("readWords() error: %v", err)
+	}
+	want := []string{"apple", "banana", "fig"}
+	if strings.Join(words, ",") != strings.Join(want, ",") {
+		t.Errorf("readWords() = %v, want %v", words, want)
+	}
+}
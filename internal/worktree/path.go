@@ -0,0 +1,60 @@
+package worktree
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PathVars holds the values available to a worktree.dir template.
+type PathVars struct {
+	RepoRoot string // git repository root
+	HomeDir  string // user home directory, used to expand "~"
+	Name     string // worktree name
+	Profile  string // profile name
+}
+
+var placeholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z_]*)\s*\}\}`)
+
+// Path resolves the location of a new worktree from a worktree.dir setting.
+//
+// dir may be absolute, start with "~", or be relative to the repository root.
+// It may contain the placeholders {{repo}} (repository directory name),
+// {{name}} (worktree name) and {{profile}} (profile name). If dir does not
+// contain {{name}}, the worktree is placed in a subdirectory named after it.
+func Path(dir string, vars PathVars) (string, error) {
+	hasName := false
+	var unknown []string
+	expanded := placeholderRe.ReplaceAllStringFunc(dir, func(m string) string {
+		switch placeholderRe.FindStringSubmatch(m)[1] {
+		case "repo":
+			return filepath.Base(vars.RepoRoot)
+		case "name":
+			hasName = true
+			return vars.Name
+		case "profile":
+			return vars.Profile
+		default:
+			unknown = append(unknown, m)
+			return m
+		}
+	})
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("unknown placeholder %s in worktree dir %q (available: {{repo}}, {{name}}, {{profile}})", unknown[0], dir)
+	}
+
+	switch {
+	case expanded == "~":
+		expanded = vars.HomeDir
+	case strings.HasPrefix(expanded, "~/"):
+		expanded = filepath.Join(vars.HomeDir, expanded[2:])
+	case !filepath.IsAbs(expanded):
+		expanded = filepath.Join(vars.RepoRoot, expanded)
+	}
+
+	if !hasName {
+		expanded = filepath.Join(expanded, vars.Name)
+	}
+	return filepath.Clean(expanded), nil
+}
@@ -0,0 +1,54 @@
+package worktree
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	vars := PathVars{
+		RepoRoot: "/src/my-repo",
+		HomeDir:  "/home/user",
+		Name:     "red-fox-jump",
+		Profile:  "worktree-zellij",
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		want string
+	}{
+		{"relative default", "worktrees", "/src/my-repo/worktrees/red-fox-jump"},
+		{"relative nested", ".aw/trees", "/src/my-repo/.aw/trees/red-fox-jump"},
+		{"absolute", "/tmp/aw", "/tmp/aw/red-fox-jump"},
+		{"home", "~", "/home/user/red-fox-jump"},
+		{"home subdir", "~/worktrees", "/home/user/worktrees/red-fox-jump"},
+		{"templated with name", "~/worktrees/{{repo}}/{{name}}", "/home/user/worktrees/my-repo/red-fox-jump"},
+		{"templated without name", "~/worktrees/{{repo}}", "/home/user/worktrees/my-repo/red-fox-jump"},
+		{"profile placeholder", "/tmp/{{profile}}-{{name}}", "/tmp/worktree-zellij-red-fox-jump"},
+		{"spaces in placeholder", "/tmp/{{ repo }}/{{ name }}", "/tmp/my-repo/red-fox-jump"},
+		{"sibling of repo", "../{{repo}}.worktrees", "/src/my-repo.worktrees/red-fox-jump"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Path(tt.dir, vars)
+			if err != nil {
+				t.Fatalf("Path() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Path(%q) = %q, want %q", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPath_UnknownPlaceholder(t *testing.T) {
+	_, err := Path("~/worktrees/{{branch}}", PathVars{RepoRoot: "/repo", HomeDir: "/home/user", Name: "x"})
+	if err == nil {
+		t.Fatal("expected error for unknown placeholder")
+	}
+	if !strings.Contains(err.Error(), "{{branch}}") {
+		t.Errorf("error = %q, want mention of {{branch}}", err.Error())
+	}
+}